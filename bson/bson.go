@@ -0,0 +1,67 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+// Package bson stores Perl versions in MongoDB as BSON strings. It's a
+// separate package so perl_version itself stays free of the MongoDB driver.
+package bson
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cmburn/perl_version"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Version wraps a perl_version.Version so it's marshaled to and from a BSON
+// string of its original form. All of the Version methods are available
+// through the embedding.
+type Version struct {
+	perl_version.Version
+}
+
+// MarshalBSONValue implements the bson.ValueMarshaler interface. The version
+// is stored as its original string, or as null for the zero-value Version,
+// which has none.
+func (v Version) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if v.Raw() == "" {
+		return bsontype.Null, nil, nil
+	}
+	return bsontype.String, bsoncore.AppendString(nil, v.Raw()), nil
+}
+
+// UnmarshalBSONValue implements the bson.ValueUnmarshaler interface. A string
+// is parsed as a version, and null gives the zero-value Version.
+func (v *Version) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.Null:
+		*v = Version{}
+		return nil
+	case bsontype.String:
+		s, _, ok := bsoncore.ReadString(data)
+		if !ok {
+			return errors.New("invalid version: malformed BSON string")
+		}
+		pv, err := perl_version.Parse(s)
+		if err != nil {
+			return err
+		}
+		v.Version = pv
+		return nil
+	default:
+		return fmt.Errorf("invalid version: expected a BSON string, got "+
+			"%s", t)
+	}
+}
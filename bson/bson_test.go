@@ -0,0 +1,87 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+package bson
+
+import (
+	"testing"
+
+	"github.com/cmburn/perl_version"
+	mongobson "go.mongodb.org/mongo-driver/bson"
+)
+
+type module struct {
+	Name    string  `bson:"name"`
+	Version Version `bson:"version"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []string{"v1.2.3", "1.02", "1.2_3", "undef", "v5.36.0"}
+	for _, test := range tests {
+		in := module{Name: "Foo::Bar",
+			Version: Version{perl_version.MustParse(test)}}
+		data, err := mongobson.Marshal(in)
+		if err != nil {
+			t.Fatalf("bson.Marshal(%q) returned error: %v", test, err)
+		}
+		var raw struct {
+			Version string `bson:"version"`
+		}
+		if err := mongobson.Unmarshal(data, &raw); err != nil {
+			t.Fatalf("bson.Unmarshal(%q) as a string returned error: %v",
+				test, err)
+		}
+		if raw.Version != test {
+			t.Errorf("bson.Marshal(%q) stored %q, expected %q", test,
+				raw.Version, test)
+		}
+		var out module
+		if err := mongobson.Unmarshal(data, &out); err != nil {
+			t.Fatalf("bson.Unmarshal(%q) returned error: %v", test, err)
+		}
+		if !out.Version.Identical(&in.Version.Version) {
+			t.Errorf("bson round trip of %q => %q, expected %q", test,
+				out.Version.Raw(), test)
+		}
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	data, err := mongobson.Marshal(module{Name: "Foo::Bar"})
+	if err != nil {
+		t.Fatalf("bson.Marshal() returned error: %v", err)
+	}
+	out := module{Version: Version{perl_version.MustParse("v1.2.3")}}
+	if err := mongobson.Unmarshal(data, &out); err != nil {
+		t.Fatalf("bson.Unmarshal() returned error: %v", err)
+	}
+	if out.Version.Raw() != "" || !out.Version.IsUndef() {
+		t.Errorf("bson round trip of the zero value => %q, expected the "+
+			"zero value", out.Version.Raw())
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	for _, doc := range []mongobson.M{{"version": "1.2_3_4"},
+		{"version": 42}} {
+		data, err := mongobson.Marshal(doc)
+		if err != nil {
+			t.Fatalf("bson.Marshal(%v) returned error: %v", doc, err)
+		}
+		var out module
+		if err := mongobson.Unmarshal(data, &out); err == nil {
+			t.Errorf("bson.Unmarshal(%v) => nil error, expected error",
+				doc)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package perl_version
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Builder constructs a Version from its components, for test fixtures and
+// generators where formatting a string to parse would be awkward. The methods
+// chain, as in NewBuilder().Major(1).Minor(2).Patch(3).Build().
+type Builder struct {
+	components []int64
+	alpha      bool
+	qv         bool
+}
+
+// NewBuilder returns a Builder for a stable qv version with no components yet.
+func NewBuilder() *Builder {
+	return &Builder{qv: true}
+}
+
+// set sets the component at i, filling in any before it with zeros.
+func (b *Builder) set(i int, n int64) *Builder {
+	for len(b.components) <= i {
+		b.components = append(b.components, 0)
+	}
+	b.components[i] = n
+	return b
+}
+
+// Major sets the first component.
+func (b *Builder) Major(n int64) *Builder {
+	return b.set(0, n)
+}
+
+// Minor sets the second component.
+func (b *Builder) Minor(n int64) *Builder {
+	return b.set(1, n)
+}
+
+// Patch sets the third component.
+func (b *Builder) Patch(n int64) *Builder {
+	return b.set(2, n)
+}
+
+// Component appends a component after the ones already set.
+func (b *Builder) Component(n int64) *Builder {
+	b.components = append(b.components, n)
+	return b
+}
+
+// Alpha sets whether the version is an alpha version.
+func (b *Builder) Alpha(alpha bool) *Builder {
+	b.alpha = alpha
+	return b
+}
+
+// Qv sets whether the version is a qv (dotted-decimal) version, as opposed to a
+// decimal one. Builders start out qv.
+func (b *Builder) Qv(qv bool) *Builder {
+	b.qv = qv
+	return b
+}
+
+// Build returns the version, with an original written the way Parse would
+// need to see it to give the same components: "v1.2.3" for qv versions, padded
+// to three components as Parse does, and "1.002003" for decimal ones, whose
+// components past the first have to fit in three digits. An alpha version has
+// its underscore before the last digit of a qv version's last component, with
+// a zero in front of a single digit, so {1, 2, 3} is "v1.2.0_3" and {1, 2, 34}
+// is "v1.2.3_4", or before the last group of a decimal version's fraction,
+// which then needs at least two components. Negative components, and anything
+// else that can't be written that way, are an error.
+func (b *Builder) Build() (Version, error) {
+	if len(b.components) == 0 {
+		return Version{}, errors.New("invalid version: no components")
+	}
+	if err := checkComponents(b.components); err != nil {
+		return Version{}, err
+	}
+	components := append([]int64{}, b.components...)
+	if b.qv {
+		for len(components) < 3 {
+			components = append(components, 0)
+		}
+	}
+
+	asStrings := make([]string, len(components))
+	for i, component := range components {
+		asStrings[i] = strconv.FormatInt(component, 10)
+	}
+	var original string
+	if b.qv {
+		last := asStrings[len(asStrings)-1]
+		if b.alpha {
+			// Perl merges the alpha digits into the component,
+			// and a leading zero doesn't change it
+			if len(last) < 2 {
+				last = "0" + last
+			}
+			last = last[:len(last)-1] + "_" + last[len(last)-1:]
+		}
+		original = "v" + strings.Join(append(asStrings[:len(asStrings)-1],
+			last), ".")
+	} else {
+		if b.alpha && len(components) < 2 {
+			return Version{}, errAlphaWithoutDecimal
+		}
+		var sb strings.Builder
+		sb.WriteString(asStrings[0])
+		for i, component := range components[1:] {
+			if component > 999 {
+				return Version{}, fmt.Errorf("invalid version: "+
+					"decimal component %d doesn't fit in three "+
+					"digits", component)
+			}
+			if i == 0 {
+				sb.WriteByte('.')
+			}
+			if b.alpha && i == len(components)-2 {
+				sb.WriteByte('_')
+			}
+			fmt.Fprintf(&sb, "%03d", component)
+		}
+		original = sb.String()
+	}
+
+	// the original has to parse back to exactly this
+	pv, err := Parse(original)
+	if err != nil {
+		return Version{}, err
+	}
+	if !reflect.DeepEqual(pv.version, components) || pv.alpha != b.alpha ||
+		pv.qv != b.qv {
+		return Version{}, errors.New("invalid version: components " +
+			"can't be written as " + original)
+	}
+	return pv, nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package perl_version
+
+import "testing"
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		builder  *Builder
+		expected string
+	}{
+		{NewBuilder().Major(1).Minor(2).Patch(3), "v1.2.3"},
+		{NewBuilder().Major(5).Minor(36), "v5.36.0"},
+		{NewBuilder().Major(1), "v1.0.0"},
+		{NewBuilder().Patch(4), "v0.0.4"},
+		{NewBuilder().Major(1).Minor(2).Patch(3).Component(4), "v1.2.3.4"},
+		{NewBuilder().Component(1).Component(2).Component(3), "v1.2.3"},
+		{NewBuilder().Major(1).Minor(2).Patch(34).Alpha(true),
+			"v1.2.3_4"},
+		{NewBuilder().Major(1).Minor(2).Patch(3).Alpha(true),
+			"v1.2.0_3"},
+		{NewBuilder().Major(1).Minor(2).Alpha(true), "v1.2.0_0"},
+		{NewBuilder().Major(1).Minor(2).Patch(3).Qv(false), "1.002003"},
+		{NewBuilder().Major(1).Minor(2).Qv(false), "1.002"},
+		{NewBuilder().Major(1).Qv(false), "1"},
+		{NewBuilder().Major(1).Minor(20).Patch(300).Qv(false).Alpha(true),
+			"1.020_300"},
+		{NewBuilder().Major(1).Minor(0).Patch(0).Qv(false), "1.000000"},
+	}
+	for _, test := range tests {
+		actual, err := test.builder.Build()
+		if err != nil {
+			t.Errorf("Builder.Build() for %q returned error: %v",
+				test.expected, err)
+			continue
+		}
+		if actual.Raw() != test.expected {
+			t.Errorf("Builder.Build() => %q, expected %q",
+				actual.Raw(), test.expected)
+		}
+		expected := MustParse(test.expected)
+		if !actual.Identical(&expected) {
+			t.Errorf("Builder.Build() => %#v, expected %#v", actual,
+				expected)
+		}
+	}
+
+	invalid := []*Builder{
+		NewBuilder(),
+		NewBuilder().Major(1).Alpha(true).Qv(false),
+		NewBuilder().Major(1).Minor(1000).Qv(false),
+		NewBuilder().Major(1).Minor(-2).Patch(3),
+		NewBuilder().Major(-1),
+		NewBuilder().Major(1).Minor(-2).Qv(false),
+	}
+	for _, builder := range invalid {
+		if pv, err := builder.Build(); err == nil {
+			t.Errorf("Builder.Build() => %q, expected error", pv.Raw())
+		}
+	}
+}
+
+func TestBuilderNegative(t *testing.T) {
+	_, err := NewBuilder().Major(1).Minor(-2).Patch(3).Build()
+	if err != errNegativeComponent {
+		t.Errorf("Builder.Build() with a negative component => %v, "+
+			"expected %v", err, errNegativeComponent)
+	}
+}
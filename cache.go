@@ -0,0 +1,39 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+package perl_version
+
+import "sync"
+
+// parseCache maps input strings to the Versions ParseCached has already
+// parsed from them.
+var parseCache sync.Map
+
+// ParseCached works like Parse, but remembers every version it successfully
+// parses, so a string that turns up again (say "v5.34.0", thousands of times
+// over) is only parsed once. Strings that fail to parse aren't cached.
+//
+// The returned Versions share their components with the cached copy, so they
+// must be treated as read-only; Clone one before changing it. The cache is
+// never evicted, so it's best kept to inputs drawn from a bounded set.
+func ParseCached(version string) (Version, error) {
+	if cached, ok := parseCache.Load(version); ok {
+		return cached.(Version), nil
+	}
+	pv, err := Parse(version)
+	if err != nil {
+		return Version{}, err
+	}
+	parseCache.Store(version, pv)
+	return pv, nil
+}
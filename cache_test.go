@@ -0,0 +1,81 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+package perl_version
+
+import "testing"
+
+func TestParseCached(t *testing.T) {
+	tests := []string{"v5.34.0", "1.02", "v1.2.3_4", "undef", "v5.34.0"}
+	for _, test := range tests {
+		expected, _ := Parse(test)
+		actual, err := ParseCached(test)
+		if err != nil {
+			t.Errorf("ParseCached(%q) => %v, expected nil", test, err)
+			continue
+		}
+		if !actual.StrictEqual(&expected) || actual.Raw() != expected.Raw() {
+			t.Errorf("ParseCached(%q) => %q, expected %q", test,
+				actual.Raw(), expected.Raw())
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := ParseCached("1.2_3_4"); err == nil {
+			t.Errorf("ParseCached(%q) => nil, expected error", "1.2_3_4")
+		}
+	}
+}
+
+var benchmarkInputs = []string{"v5.34.0", "1.02", "v1.2.3_4", "0.001"}
+
+func BenchmarkParse(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = Parse(benchmarkInputs[i%len(benchmarkInputs)])
+	}
+}
+
+// benchmarkInvalidInputs are rejected by every grammar, which is where
+// ParseBytes saves its copy
+var benchmarkInvalidInputs = []string{"foo", "1.2.3-beta", "version 5",
+	"v1..2"}
+
+// compare with BenchmarkParseBytesInvalid for the allocations
+func BenchmarkParseStringConversionInvalid(b *testing.B) {
+	inputs := make([][]byte, len(benchmarkInvalidInputs))
+	for i, input := range benchmarkInvalidInputs {
+		inputs[i] = []byte(input)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Parse(string(inputs[i%len(inputs)]))
+	}
+}
+
+func BenchmarkParseBytesInvalid(b *testing.B) {
+	inputs := make([][]byte, len(benchmarkInvalidInputs))
+	for i, input := range benchmarkInvalidInputs {
+		inputs[i] = []byte(input)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = ParseBytes(inputs[i%len(inputs)])
+	}
+}
+
+func BenchmarkParseCached(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = ParseCached(benchmarkInputs[i%len(benchmarkInputs)])
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package perl_version
+
+// This file holds version constraints, as used for prerequisites in CPAN
+// metadata (see CPAN::Meta::Spec), e.g. ">= 1.2.3".
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Operator is the comparison a Constraint makes against its version.
+type Operator int
+
+const (
+	// OpGreaterThanOrEqual is ">=". It's the zero value, since a bare
+	// version in CPAN metadata means a minimum version.
+	OpGreaterThanOrEqual Operator = iota
+	OpGreaterThan
+	OpLessThanOrEqual
+	OpLessThan
+	OpEqual
+	OpNotEqual
+	// OpCompatible is "~>", the "compatible release" operator from Ruby
+	// and pip. The version's last written component may go up, but the
+	// rest are fixed: "~> 1.2.3" is ">= 1.2.3, < 1.3.0", and "~> 1.2" is
+	// ">= 1.2, < 2.0", as is "~> 1.2345". A single component is bumped
+	// itself, so "~> 1" is ">= 1, < 2".
+	OpCompatible
+	// OpAny is "*", which matches every version. It takes no version.
+	OpAny
+)
+
+// operators is ordered so that two-character operators are tried first.
+var operators = []struct {
+	symbol string
+	op     Operator
+}{
+	{">=", OpGreaterThanOrEqual},
+	{"~>", OpCompatible},
+	{"<=", OpLessThanOrEqual},
+	{"==", OpEqual},
+	{"!=", OpNotEqual},
+	{">", OpGreaterThan},
+	{"<", OpLessThan},
+	{"*", OpAny},
+}
+
+// String returns the operator's symbol, e.g. ">=".
+func (o Operator) String() string {
+	for _, operator := range operators {
+		if operator.op == o {
+			return operator.symbol
+		}
+	}
+	return "?"
+}
+
+// Constraint is a requirement on a version, e.g. ">= 1.2.3".
+type Constraint struct {
+	Op      Operator
+	Version Version
+	// ExcludeAlpha makes the constraint reject alpha versions, even if
+	// they'd otherwise match. A version's alpha flag comes from parsing,
+	// i.e. whether it had an underscore, so with this set ">= 1.2.3" still
+	// accepts "v1.2.4" but not "v1.2.3_1".
+	ExcludeAlpha bool
+}
+
+// ParseConstraint parses a constraint, which is an optional operator followed
+// by a version. A bare version means a minimum, so "1.2.3" is the same as
+// ">= 1.2.3". The exception is "*", which stands alone.
+func ParseConstraint(constraint string) (Constraint, error) {
+	trimmed := strings.TrimSpace(constraint)
+	c := Constraint{Op: OpGreaterThanOrEqual}
+	for _, operator := range operators {
+		if strings.HasPrefix(trimmed, operator.symbol) {
+			c.Op = operator.op
+			trimmed = strings.TrimPrefix(trimmed, operator.symbol)
+			trimmed = strings.TrimSpace(trimmed)
+			break
+		}
+	}
+	if c.Op == OpAny {
+		if trimmed != "" {
+			return Constraint{}, errors.New("invalid constraint: " +
+				"unexpected version after * in " + constraint)
+		}
+		return c, nil
+	}
+	if trimmed == "" {
+		return Constraint{}, errors.New("invalid constraint: missing " +
+			"version in " + constraint)
+	}
+	v, err := Parse(trimmed)
+	if err != nil {
+		return Constraint{}, err
+	}
+	c.Version = v
+	return c, nil
+}
+
+// Matches checks whether a version satisfies the constraint.
+func (c Constraint) Matches(v *Version) bool {
+	if c.ExcludeAlpha && v.IsAlpha() {
+		return false
+	}
+	switch c.Op {
+	case OpGreaterThanOrEqual:
+		return v.GreaterThanOrEqual(&c.Version)
+	case OpGreaterThan:
+		return v.GreaterThan(&c.Version)
+	case OpLessThanOrEqual:
+		return v.LessThanOrEqual(&c.Version)
+	case OpLessThan:
+		return v.LessThan(&c.Version)
+	case OpEqual:
+		return v.Equal(&c.Version)
+	case OpNotEqual:
+		return v.NotEqual(&c.Version)
+	case OpCompatible:
+		ceiling := c.compatibleCeiling()
+		return v.GreaterThanOrEqual(&c.Version) && v.LessThan(&ceiling)
+	case OpAny:
+		return true
+	default:
+		return false
+	}
+}
+
+// compatibleCeiling returns the exclusive upper bound for OpCompatible: the
+// version with its last written component dropped and the one before it
+// incremented. A decimal version is written as an integer and a fraction,
+// however many digits the fraction has, so its ceiling is the next integer:
+// "~> 1.2" and "~> 1.2345" both mean below 2.
+func (c Constraint) compatibleCeiling() Version {
+	written := c.Version.writtenComponents()
+	if !c.Version.qv {
+		written = written[:min(len(written), 1)]
+	}
+	ceiling := append([]int64{}, written[:max(len(written)-1, 1)]...)
+	ceiling[len(ceiling)-1]++
+	asStrings := make([]string, len(ceiling))
+	for i, component := range ceiling {
+		asStrings[i] = strconv.FormatInt(component, 10)
+	}
+	// padded like parsing the original would, so they're Identical
+	for len(ceiling) < 3 {
+		ceiling = append(ceiling, 0)
+	}
+	return Version{
+		original: "v" + strings.Join(asStrings, "."),
+		qv:       true,
+		version:  ceiling,
+	}
+}
+
+// String returns the constraint in the form ParseConstraint accepts.
+func (c Constraint) String() string {
+	if c.Op == OpAny {
+		return c.Op.String()
+	}
+	return c.Op.String() + " " + c.Version.Stringify()
+}
+
+// ConstraintSet is a list of constraints that all have to be met, e.g.
+// ">= 1.2, < 2.0, != 1.5" as used for version ranges in CPAN metadata.
+type ConstraintSet struct {
+	Constraints []Constraint
+	// IncludeAlpha lets alpha versions match. Most resolvers don't
+	// consider pre-releases unless asked to, so by default an alpha
+	// version only matches if one of the constraints names an alpha
+	// version itself, as in "== 1.2_3".
+	IncludeAlpha bool
+}
+
+// ConstraintSetOption changes how ParseConstraintSet builds a ConstraintSet.
+type ConstraintSetOption func(*ConstraintSet)
+
+// WithIncludeAlpha lets alpha versions match the constraint set, see
+// ConstraintSet.IncludeAlpha.
+func WithIncludeAlpha() ConstraintSetOption {
+	return func(cs *ConstraintSet) {
+		cs.IncludeAlpha = true
+	}
+}
+
+// ParseConstraintSet parses a comma-separated list of constraints, each in
+// the form ParseConstraint accepts.
+func ParseConstraintSet(constraints string,
+	opts ...ConstraintSetOption) (ConstraintSet, error) {
+	cs := ConstraintSet{}
+	for _, constraint := range strings.Split(constraints, ",") {
+		c, err := ParseConstraint(constraint)
+		if err != nil {
+			return ConstraintSet{}, err
+		}
+		cs.Constraints = append(cs.Constraints, c)
+	}
+	for _, opt := range opts {
+		opt(&cs)
+	}
+	return cs, nil
+}
+
+// Matches checks whether a version satisfies every constraint in the set.
+func (cs ConstraintSet) Matches(v *Version) bool {
+	if v.IsAlpha() && !cs.IncludeAlpha && !cs.namesAlpha() {
+		return false
+	}
+	for _, c := range cs.Constraints {
+		if !c.Matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// namesAlpha checks whether any of the constraints are on an alpha version.
+func (cs ConstraintSet) namesAlpha() bool {
+	for _, c := range cs.Constraints {
+		if c.Version.IsAlpha() {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the constraint set in the form ParseConstraintSet accepts.
+func (cs ConstraintSet) String() string {
+	constraints := make([]string, len(cs.Constraints))
+	for i, c := range cs.Constraints {
+		constraints[i] = c.String()
+	}
+	return strings.Join(constraints, ", ")
+}
+
+// Select returns the newest of the candidates that satisfies the constraint,
+// and false if none do. This is the usual way to resolve a dependency against
+// the versions on offer. Between candidates that only differ by being alpha,
+// the stable one wins, as CompareStable orders them.
+func Select(candidates []Version, c Constraint) (Version, bool) {
+	var selected Version
+	found := false
+	for i := range candidates {
+		if !c.Matches(&candidates[i]) {
+			continue
+		}
+		if !found || candidates[i].CompareStable(&selected) > 0 {
+			selected = candidates[i]
+			found = true
+		}
+	}
+	return selected, found
+}
+
+// ParseWildcard parses a wildcard version, as found in some config files,
+// into a Constraint. The wildcard, "*" or "x", may only stand in for the last
+// component, which is read as a dotted version: "1.2.*" is the same as
+// "~> v1.2.0", i.e. ">= 1.2.0, < 1.3.0", and "1.*" is ">= 1.0, < 2.0". A bare
+// wildcard matches everything.
+func ParseWildcard(wildcard string) (Constraint, error) {
+	trimmed := strings.TrimSpace(wildcard)
+	if trimmed == "*" || trimmed == "x" {
+		return Constraint{Op: OpAny}, nil
+	}
+	prefix := strings.TrimSuffix(trimmed, ".*")
+	if prefix == trimmed {
+		prefix = strings.TrimSuffix(trimmed, ".x")
+	}
+	if prefix == trimmed {
+		return Constraint{}, errors.New("invalid wildcard version: " +
+			wildcard)
+	}
+	prefix = strings.TrimPrefix(prefix, "v")
+	if prefix == "" ||
+		strings.Trim(prefix, "0123456789.") != "" ||
+		strings.HasPrefix(prefix, ".") || strings.Contains(prefix, "..") {
+		return Constraint{}, errors.New("invalid wildcard version: " +
+			wildcard)
+	}
+	v, err := Parse("v" + prefix + ".0")
+	if err != nil {
+		return Constraint{}, err
+	}
+	return Constraint{Op: OpCompatible, Version: v}, nil
+}
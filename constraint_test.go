@@ -0,0 +1,334 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+package perl_version
+
+import (
+	"testing"
+)
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		op         Operator
+		version    string
+	}{
+		{"1.2.3", OpGreaterThanOrEqual, "1.2.3"},
+		{">= 1.2.3", OpGreaterThanOrEqual, "1.2.3"},
+		{">=1.2.3", OpGreaterThanOrEqual, "1.2.3"},
+		{" > v1.2 ", OpGreaterThan, "v1.2"},
+		{"<= 1.02_03", OpLessThanOrEqual, "1.02_03"},
+		{"< 2", OpLessThan, "2"},
+		{"== 0.5", OpEqual, "0.5"},
+		{"!= undef", OpNotEqual, "undef"},
+	}
+	for _, test := range tests {
+		c, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q) returned error: %v",
+				test.constraint, err)
+			continue
+		}
+		if c.Op != test.op || c.Version.Raw() != test.version {
+			t.Errorf("ParseConstraint(%q) => %q, expected %q",
+				test.constraint, c.String(),
+				test.op.String()+" "+test.version)
+		}
+	}
+
+	for _, constraint := range []string{"", ">=", ">= foo", "=> 1.2",
+		"~ 1.2"} {
+		if _, err := ParseConstraint(constraint); err == nil {
+			t.Errorf("ParseConstraint(%q) => nil error, expected "+
+				"error", constraint)
+		}
+	}
+}
+
+func TestConstraint_Matches(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{">= 1.2.3", "1.2.3", true},
+		{">= 1.2.3", "v1.2.4", true},
+		{">= 1.2.3", "v1.2.2", false},
+		{">= 1.2.3", "1.2.3_1", true},
+		{"> 1.2.3", "1.2.3", false},
+		{"> 1.2.3", "v1.2.3.1", true},
+		{"<= v1.2", "v1.2.0", true},
+		{"<= v1.2", "v1.2.1", false},
+		{"< 2", "1.999", true},
+		{"< 2", "v2.0.0", false},
+		{"== 1.2", "1.200", true},
+		{"== 1.2", "1.201", false},
+		{"!= 1.2", "1.201", true},
+		{"!= 1.2", "1.2", false},
+	}
+	for _, test := range tests {
+		c, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q) returned error: %v",
+				test.constraint, err)
+		}
+		v := MustParse(test.version)
+		if actual := c.Matches(&v); actual != test.expected {
+			t.Errorf("%q.Matches(%q) => %t, expected %t",
+				test.constraint, test.version, actual,
+				test.expected)
+		}
+	}
+}
+
+func TestConstraint_ExcludeAlpha(t *testing.T) {
+	c, err := ParseConstraint(">= 1.2.3")
+	if err != nil {
+		t.Fatalf("ParseConstraint(%q) returned error: %v", ">= 1.2.3",
+			err)
+	}
+	alpha := MustParse("1.2.3_1")
+	stable := MustParse("1.2.4")
+	if alpha.IsStable() || !stable.IsStable() {
+		t.Errorf("IsStable() disagrees with IsAlpha()")
+	}
+	if !c.Matches(&alpha) || !c.Matches(&stable) {
+		t.Errorf("%q doesn't match both %q and %q", c.String(),
+			alpha.Raw(), stable.Raw())
+	}
+	c.ExcludeAlpha = true
+	if c.Matches(&alpha) {
+		t.Errorf("%q with ExcludeAlpha matches %q", c.String(),
+			alpha.Raw())
+	}
+	if !c.Matches(&stable) {
+		t.Errorf("%q with ExcludeAlpha doesn't match %q", c.String(),
+			stable.Raw())
+	}
+}
+
+func TestSelect(t *testing.T) {
+	candidates := []Version{MustParse("1.01"), MustParse("v1.3.0"),
+		MustParse("1.2"), MustParse("v2.0.0"), MustParse("v1.2.3_0"),
+		MustParse("v1.2.30"), MustParse("0.99")}
+	tests := []struct {
+		constraint string
+		expected   string
+		found      bool
+	}{
+		{">= 1.0", "v2.0.0", true},
+		{"< 2", "1.2", true},
+		{"< v1.3.0", "v1.2.30", true},
+		{"== v1.2.30", "v1.2.30", true},
+		{"<= 1.01", "1.01", true},
+		{"> 2", "", false},
+		{"< 0.5", "", false},
+	}
+	for _, test := range tests {
+		c, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q) returned error: %v",
+				test.constraint, err)
+		}
+		selected, found := Select(candidates, c)
+		if found != test.found || selected.Raw() != test.expected {
+			t.Errorf("Select(%q) => %q, %t, expected %q, %t",
+				test.constraint, selected.Raw(), found, test.expected,
+				test.found)
+		}
+	}
+	if _, found := Select(nil, Constraint{}); found {
+		t.Errorf("Select(nil) => found, expected not found")
+	}
+}
+
+func TestConstraint_Compatible(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{"~> 1.2.3", "1.2.2", false},
+		{"~> 1.2.3", "1.2.3", true},
+		{"~> 1.2.3", "v1.2.99", true},
+		{"~> 1.2.3", "v1.3.0", false},
+		{"~> 1.2.3", "v1.3", false},
+		{"~> v1.2", "v1.2.0", true},
+		{"~> v1.2", "v1.9.9", true},
+		{"~> v1.2", "v2.0.0", false},
+		{"~> 1.2", "1.199", false},
+		{"~> 1.2", "1.2", true},
+		{"~> 1.2", "1.999", true},
+		{"~> 1.2", "v1.999.999", true},
+		{"~> 1.2", "2.0", false},
+		{"~> 1.2345", "1.2344", false},
+		{"~> 1.2345", "1.2345", true},
+		{"~> 1.2345", "1.9999", true},
+		{"~> 1.2345", "v1.999.999", true},
+		{"~> 1.2345", "2.0", false},
+		{"~> 1", "1", true},
+		{"~> 1", "v1.9.9", true},
+		{"~> 1", "2", false},
+		{"~>1.2.3", "1.2.4", true},
+	}
+	for _, test := range tests {
+		c, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q) returned error: %v",
+				test.constraint, err)
+		}
+		if c.Op != OpCompatible {
+			t.Errorf("ParseConstraint(%q).Op => %v, expected %v",
+				test.constraint, c.Op, OpCompatible)
+		}
+		v := MustParse(test.version)
+		if actual := c.Matches(&v); actual != test.expected {
+			t.Errorf("%q.Matches(%q) => %t, expected %t",
+				test.constraint, test.version, actual,
+				test.expected)
+		}
+	}
+	c, _ := ParseConstraint("~> 1.2.3")
+	if c.String() != "~> 1.2.3" {
+		t.Errorf("ParseConstraint(%q).String() => %q, expected %q",
+			"~> 1.2.3", c.String(), "~> 1.2.3")
+	}
+}
+
+func TestParseConstraintSet(t *testing.T) {
+	tests := []struct {
+		constraints string
+		version     string
+		expected    bool
+	}{
+		{">= 1.2, < 2.0", "1.5", true},
+		{">= 1.2, < 2.0", "2.0", false},
+		{">= 1.2, < 2.0, != 1.5", "1.5", false},
+		{">= 1.2, < 2.0, != 1.5", "1.6", true},
+		{"~> 1.2", "1.999", true},
+		// alpha versions are excluded by default
+		{"~> 1.2", "1.3_0", false},
+		{">= 1.2, < 2.0", "v1.5.0_1", false},
+		// unless a constraint names one
+		{">= 1.2_1, < 2.0", "1.3_0", true},
+		{"== 1.2_3", "1.2_3", true},
+	}
+	for _, test := range tests {
+		cs, err := ParseConstraintSet(test.constraints)
+		if err != nil {
+			t.Fatalf("ParseConstraintSet(%q) returned error: %v",
+				test.constraints, err)
+		}
+		v := MustParse(test.version)
+		if actual := cs.Matches(&v); actual != test.expected {
+			t.Errorf("%q.Matches(%q) => %t, expected %t",
+				test.constraints, test.version, actual,
+				test.expected)
+		}
+	}
+
+	for _, invalid := range []string{"", ">= 1.2,", ">= 1.2, < foo"} {
+		if _, err := ParseConstraintSet(invalid); err == nil {
+			t.Errorf("ParseConstraintSet(%q) => nil error, expected "+
+				"error", invalid)
+		}
+	}
+
+	cs, err := ParseConstraintSet(">=1.2,<2.0")
+	if err != nil {
+		t.Fatalf("ParseConstraintSet(%q) returned error: %v",
+			">=1.2,<2.0", err)
+	}
+	if cs.String() != ">= 1.2, < 2.0" {
+		t.Errorf("ParseConstraintSet(%q).String() => %q, expected %q",
+			">=1.2,<2.0", cs.String(), ">= 1.2, < 2.0")
+	}
+}
+
+func TestConstraintSet_IncludeAlpha(t *testing.T) {
+	alpha := MustParse("1.3_0")
+	cs, err := ParseConstraintSet("~> 1.2")
+	if err != nil {
+		t.Fatalf("ParseConstraintSet(%q) returned error: %v", "~> 1.2",
+			err)
+	}
+	if cs.Matches(&alpha) {
+		t.Errorf("%q.Matches(%q) => true, expected false", cs.String(),
+			alpha.Raw())
+	}
+	cs, err = ParseConstraintSet("~> 1.2", WithIncludeAlpha())
+	if err != nil {
+		t.Fatalf("ParseConstraintSet(%q) returned error: %v", "~> 1.2",
+			err)
+	}
+	if !cs.IncludeAlpha || !cs.Matches(&alpha) {
+		t.Errorf("%q with WithIncludeAlpha().Matches(%q) => false, "+
+			"expected true", cs.String(), alpha.Raw())
+	}
+}
+
+func TestParseWildcard(t *testing.T) {
+	tests := []struct {
+		wildcard string
+		version  string
+		expected bool
+	}{
+		{"1.2.*", "v1.1.999", false},
+		{"1.2.*", "v1.2.0", true},
+		{"1.2.*", "v1.2", true},
+		{"1.2.*", "v1.2.999", true},
+		{"1.2.*", "v1.3.0", false},
+		{"1.2.x", "v1.2.5", true},
+		{"v1.2.*", "v1.2.5", true},
+		{"1.*", "0.999", false},
+		{"1.*", "1.0", true},
+		{"1.*", "v1.999.999", true},
+		{"1.*", "2.0", false},
+		{"1.2.3.*", "v1.2.3.4", true},
+		{"1.2.3.*", "v1.2.4", false},
+		{"*", "undef", true},
+		{"*", "v999.999.999", true},
+		{"x", "1.2_3", true},
+	}
+	for _, test := range tests {
+		c, err := ParseWildcard(test.wildcard)
+		if err != nil {
+			t.Fatalf("ParseWildcard(%q) returned error: %v",
+				test.wildcard, err)
+		}
+		v := MustParse(test.version)
+		if actual := c.Matches(&v); actual != test.expected {
+			t.Errorf("ParseWildcard(%q).Matches(%q) => %t, expected %t",
+				test.wildcard, test.version, actual, test.expected)
+		}
+	}
+
+	invalid := []string{"", "1.2", "*.2", "1.*.3", ".*", "1..*", "a.*",
+		"1.2_3.*", "v1.2", "v1"}
+	for _, wildcard := range invalid {
+		if _, err := ParseWildcard(wildcard); err == nil {
+			t.Errorf("ParseWildcard(%q) => nil error, expected error",
+				wildcard)
+		}
+	}
+
+	c, err := ParseConstraint("*")
+	if err != nil || c.Op != OpAny || c.String() != "*" {
+		t.Errorf("ParseConstraint(%q) => %q, %v, expected %q", "*",
+			c.String(), err, "*")
+	}
+	if _, err := ParseConstraint("* 1.2"); err == nil {
+		t.Errorf("ParseConstraint(%q) => nil error, expected error",
+			"* 1.2")
+	}
+}
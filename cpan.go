@@ -0,0 +1,182 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+package perl_version
+
+// Reading prerequisites from CPAN distribution metadata, i.e. cpanfiles and
+// META.json.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var cpanfileRequirementRegexp = regexp.MustCompile(`^\s*(?:requires|` +
+	`recommends|suggests|conflicts|(?:test|build|configure|author)_requires)` +
+	`\s+(?:'([\w:]+)'|"([\w:]+)")\s*(?:(?:,|=>)\s*(?:'([^']*)'|"([^"]*)"|` +
+	`(v?[0-9][0-9._]*)))?\s*;?\s*(?:#.*)?$`)
+
+// ParseCpanfileRequirement parses a single requirement line from a cpanfile,
+// such as `requires 'Foo::Bar', '>= 1.2.3';`, into the module name and its
+// constraints. Any of the requirement keywords are accepted (requires,
+// recommends, test_requires and so on), with the module and version in single
+// or double quotes, or the version bare. A line without a version means any
+// version will do, the same as "0".
+func ParseCpanfileRequirement(line string) (string, ConstraintSet, error) {
+	match := cpanfileRequirementRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return "", ConstraintSet{}, errors.New("invalid cpanfile " +
+			"requirement: " + line)
+	}
+	module := match[1] + match[2]
+	constraint := match[3] + match[4] + match[5]
+	if strings.TrimSpace(constraint) == "" {
+		constraint = "0"
+	}
+	cs, err := ParseConstraintSet(constraint)
+	if err != nil {
+		return "", ConstraintSet{}, err
+	}
+	return module, cs, nil
+}
+
+// ParseMetaPrereqs reads the prereqs from a META.json file (see
+// CPAN::Meta::Spec), which are nested as phase, then relationship, then
+// module to version range, and flattens them into a map of module to
+// constraints. Only the "requires" relationship is taken, since the others
+// (recommends, suggests and conflicts) aren't requirements; a module required
+// in more than one phase gets the constraints from all of them, in order of
+// the phases' names. A version range of 0 means any version.
+func ParseMetaPrereqs(data []byte) (map[string]ConstraintSet, error) {
+	type requirements map[string]json.RawMessage
+	var meta struct {
+		Prereqs map[string]map[string]requirements `json:"prereqs"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("invalid META.json: %w", err)
+	}
+	prereqs := make(map[string]ConstraintSet)
+	for _, phase := range slices.Sorted(maps.Keys(meta.Prereqs)) {
+		requires := meta.Prereqs[phase]["requires"]
+		for _, module := range slices.Sorted(maps.Keys(requires)) {
+			raw := requires[module]
+			// version ranges are meant to be strings, but bare
+			// numbers turn up in the wild
+			var constraint string
+			if err := json.Unmarshal(raw, &constraint); err != nil {
+				constraint = string(raw)
+			}
+			cs, err := ParseConstraintSet(constraint)
+			if err != nil {
+				return nil, fmt.Errorf("invalid META.json: %s "+
+					"requirement on %s: %w", phase, module, err)
+			}
+			existing := prereqs[module]
+			existing.Constraints = append(existing.Constraints,
+				cs.Constraints...)
+			prereqs[module] = existing
+		}
+	}
+	return prereqs, nil
+}
+
+// SatisfiesPerl checks whether the version, taken to be that of a perl
+// interpreter, meets a "perl" prerequisite from CPAN metadata, such as
+// "5.010001". That's usually a decimal version, though any version Parse
+// accepts will do, e.g. "v5.10.1". Either way it's a minimum, so the running
+// perl satisfies it if it's the same or newer.
+func (v *Version) SatisfiesPerl(required string) (bool, error) {
+	pv, err := Parse(strings.TrimSpace(required))
+	if err != nil {
+		return false, err
+	}
+	return v.GreaterThanOrEqual(&pv), nil
+}
+
+// PerlDecimalToQv converts a perl interpreter's version from the decimal form,
+// as in $], to the dotted form, as in $^V: "5.034000" becomes v5.34.0, and
+// "5.008009" becomes v5.8.9. The decimal form has six fractional digits, three
+// for the minor version and three for the patch, though shorter ones such as
+// "5.010" are read as Perl would, padded with zeros. A version that's already
+// dotted is just put in normal form. Anything with more than three components
+// isn't a perl version, and is an error.
+func PerlDecimalToQv(version string) (Version, error) {
+	pv, err := Parse(strings.TrimSpace(version))
+	if err != nil {
+		return Version{}, err
+	}
+	if pv.SignificantComponents() > 3 {
+		return Version{}, errors.New("invalid perl version: " + version +
+			" has more than three components")
+	}
+	canonical := pv.Canonical()
+	canonical.version = canonical.version[:3]
+	canonical.original = canonical.Normal()
+	return canonical, nil
+}
+
+// PerlQvToDecimal converts a perl interpreter's version from the dotted form
+// to the decimal form, the reverse of PerlDecimalToQv: v5.34.0 becomes
+// "5.034000", and v5.8.9 becomes "5.008009". The result is always six
+// fractional digits, so the minor and patch versions have to be below 1000 to
+// fit in their three. A version that doesn't fit, has more than three
+// components, or is an alpha version isn't a perl release, and is an error.
+func PerlQvToDecimal(v *Version) (string, error) {
+	if v.SignificantComponents() > 3 {
+		return "", errors.New("invalid perl version: " + v.Raw() +
+			" has more than three components")
+	}
+	if v.IsAlpha() {
+		return "", errors.New("invalid perl version: " + v.Raw() +
+			" is an alpha version")
+	}
+	if v.component(1) >= 1000 || v.component(2) >= 1000 {
+		return "", errors.New("invalid perl version: " + v.Raw() +
+			" has a component over 999")
+	}
+	return fmt.Sprintf("%d.%03d%03d", v.component(0), v.component(1),
+		v.component(2)), nil
+}
+
+// IsValidModuleVersion checks a module's $VERSION against the rules the PAUSE
+// indexer and most of the CPAN toolchain follow, returning why it's rejected
+// if it is. On top of being a version at all, it can't be "undef", it can't be
+// an alpha version, which PAUSE won't index, and it has to be a strict
+// version, so "v1.2" and "1." are out, as are leading zeros. Whether it's
+// newer than what's already indexed, which PAUSE also requires, needs the
+// indexed version to compare against, so that's left to the caller.
+func IsValidModuleVersion(version string) (bool, string) {
+	if version == "" {
+		return false, "the version is empty"
+	}
+	pv, err := Parse(version)
+	if err != nil {
+		return false, err.Error()
+	}
+	if pv.IsUndef() {
+		return false, "undef isn't a version"
+	}
+	if pv.alpha {
+		return false, version + " is an alpha version, which PAUSE " +
+			"won't index"
+	}
+	if _, err := ParseStrict(version); err != nil {
+		return false, err.Error()
+	}
+	return true, ""
+}
@@ -0,0 +1,312 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+package perl_version
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCpanfileRequirement(t *testing.T) {
+	tests := []struct {
+		line        string
+		module      string
+		constraints string
+	}{
+		{`requires 'Foo::Bar', '>= 1.2.3';`, "Foo::Bar", ">= 1.2.3"},
+		{`requires "Foo::Bar", "1.2";`, "Foo::Bar", ">= 1.2"},
+		{`requires 'Foo::Bar', 1.2;`, "Foo::Bar", ">= 1.2"},
+		{`requires 'Foo::Bar' => 'v1.2.3';`, "Foo::Bar", ">= v1.2.3"},
+		{`requires 'Foo::Bar';`, "Foo::Bar", ">= 0"},
+		{`requires "Foo";`, "Foo", ">= 0"},
+		{`  test_requires 'Test::More', '0.98';  # for done_testing`,
+			"Test::More", ">= 0.98"},
+		{`recommends 'JSON::XS', '>= 2.0, < 5.0, != 3.0';`, "JSON::XS",
+			">= 2.0, < 5.0, != 3.0"},
+		{`conflicts 'Moose', '< 2.0'`, "Moose", "< 2.0"},
+		{`configure_requires "ExtUtils::MakeMaker" => "== 7.64";`,
+			"ExtUtils::MakeMaker", "== 7.64"},
+		{`requires 'perl', '5.010001';`, "perl", ">= 5.010001"},
+	}
+	for _, test := range tests {
+		module, cs, err := ParseCpanfileRequirement(test.line)
+		if err != nil {
+			t.Errorf("ParseCpanfileRequirement(%q) returned error: %v",
+				test.line, err)
+			continue
+		}
+		if module != test.module || cs.String() != test.constraints {
+			t.Errorf("ParseCpanfileRequirement(%q) => %q, %q, "+
+				"expected %q, %q", test.line, module, cs.String(),
+				test.module, test.constraints)
+		}
+	}
+
+	invalid := []string{
+		``,
+		`# requires 'Foo';`,
+		`on test => sub {`,
+		`requires Foo::Bar;`,
+		`requires 'Foo::Bar", '1.2';`,
+		`requires 'Foo::Bar', 'not a version';`,
+		`requires 'Foo::Bar', '1.2' or die;`,
+	}
+	for _, line := range invalid {
+		if _, _, err := ParseCpanfileRequirement(line); err == nil {
+			t.Errorf("ParseCpanfileRequirement(%q) => nil error, "+
+				"expected error", line)
+		}
+	}
+}
+
+func TestParseMetaPrereqs(t *testing.T) {
+	meta := []byte(`{
+	   "abstract" : "Frobnicate the bars",
+	   "name" : "Foo-Bar",
+	   "prereqs" : {
+	      "configure" : {
+	         "requires" : {
+	            "ExtUtils::MakeMaker" : "0"
+	         }
+	      },
+	      "runtime" : {
+	         "recommends" : {
+	            "JSON::XS" : "2.0"
+	         },
+	         "requires" : {
+	            "perl" : "5.010001",
+	            "Moo" : ">= 2.0, < 3.0",
+	            "List::Util" : 1.45
+	         }
+	      },
+	      "test" : {
+	         "requires" : {
+	            "Test::More" : "0.98",
+	            "Moo" : "!= 2.003"
+	         }
+	      }
+	   },
+	   "version" : "1.02"
+	}`)
+	prereqs, err := ParseMetaPrereqs(meta)
+	if err != nil {
+		t.Fatalf("ParseMetaPrereqs() returned error: %v", err)
+	}
+	expected := map[string]string{
+		"ExtUtils::MakeMaker": ">= 0",
+		"perl":                ">= 5.010001",
+		"List::Util":          ">= 1.45",
+		"Test::More":          ">= 0.98",
+	}
+	if len(prereqs) != len(expected)+1 {
+		t.Errorf("ParseMetaPrereqs() => %d modules, expected %d",
+			len(prereqs), len(expected)+1)
+	}
+	for module, constraints := range expected {
+		if prereqs[module].String() != constraints {
+			t.Errorf("ParseMetaPrereqs()[%q] => %q, expected %q", module,
+				prereqs[module].String(), constraints)
+		}
+	}
+	// Moo is in two phases, so it has to meet both, runtime's first
+	moo := prereqs["Moo"]
+	if moo.String() != ">= 2.0, < 3.0, != 2.003" {
+		t.Errorf("ParseMetaPrereqs()[%q] => %q, expected %q", "Moo",
+			moo.String(), ">= 2.0, < 3.0, != 2.003")
+	}
+	for i := 0; i < 20; i++ {
+		again, _ := ParseMetaPrereqs(meta)
+		if again["Moo"].String() != moo.String() {
+			t.Errorf("ParseMetaPrereqs()[%q] => %q, then %q", "Moo",
+				moo.String(), again["Moo"].String())
+		}
+	}
+	for version, expected := range map[string]bool{"2.002": true,
+		"2.003": false, "3.0": false} {
+		v := MustParse(version)
+		if moo.Matches(&v) != expected {
+			t.Errorf("ParseMetaPrereqs()[%q].Matches(%q) => %t, "+
+				"expected %t", "Moo", version, !expected, expected)
+		}
+	}
+	if _, ok := prereqs["JSON::XS"]; ok {
+		t.Errorf("ParseMetaPrereqs() included a recommendation")
+	}
+
+	invalid := []string{`not json`,
+		`{"prereqs": {"runtime": {"requires": {"Foo": "foo"}}}}`,
+		`{"prereqs": {"runtime": {"requires": {"Foo": null}}}}`}
+	for _, data := range invalid {
+		if _, err := ParseMetaPrereqs([]byte(data)); err == nil {
+			t.Errorf("ParseMetaPrereqs(%s) => nil error, expected error",
+				data)
+		}
+	}
+}
+
+func TestSatisfiesPerl(t *testing.T) {
+	tests := []struct {
+		perl     string
+		required string
+		expected bool
+	}{
+		{"v5.36.0", "5.010001", true},
+		{"v5.10.1", "5.010001", true},
+		{"v5.10.0", "5.010001", false},
+		{"5.008009", "5.010001", false},
+		{"v5.8.9", "5.008009", true},
+		{"v5.34.0", "5.034000", true},
+		{"v5.34.0", "5.036", false},
+		{"v5.36.0", "v5.36.1", false},
+		{"v5.36.0", "0", true},
+		{"v5.36.0", " 5.006 ", true},
+	}
+	for _, test := range tests {
+		perl := MustParse(test.perl)
+		actual, err := perl.SatisfiesPerl(test.required)
+		if err != nil {
+			t.Errorf("NewPerlVersion(%q).SatisfiesPerl(%q) returned "+
+				"error: %v", test.perl, test.required, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("NewPerlVersion(%q).SatisfiesPerl(%q) => %t, "+
+				"expected %t", test.perl, test.required, actual,
+				test.expected)
+		}
+	}
+	perl := MustParse("v5.36.0")
+	if _, err := perl.SatisfiesPerl("5.x"); err == nil {
+		t.Errorf("SatisfiesPerl(%q) => nil error, expected error", "5.x")
+	}
+}
+
+func TestPerlDecimalToQv(t *testing.T) {
+	tests := []struct {
+		decimal string
+		qv      string
+	}{
+		{"5.034000", "v5.34.0"},
+		{"5.008009", "v5.8.9"},
+		{"5.010001", "v5.10.1"},
+		{"5.010", "v5.10.0"},
+		{"5.6", "v5.600.0"},
+		{"5", "v5.0.0"},
+		{"v5.36.0", "v5.36.0"},
+		{"5.36.0", "v5.36.0"},
+		{"5.034000000", "v5.34.0"},
+	}
+	for _, test := range tests {
+		pv, err := PerlDecimalToQv(test.decimal)
+		if err != nil {
+			t.Errorf("PerlDecimalToQv(%q) returned error: %v",
+				test.decimal, err)
+			continue
+		}
+		expected := MustParse(test.qv)
+		if !pv.Identical(&expected) {
+			t.Errorf("PerlDecimalToQv(%q) => %#v, expected %#v",
+				test.decimal, pv, expected)
+		}
+	}
+	for _, invalid := range []string{"5.034000001", "v5.36.0.1", "five"} {
+		if _, err := PerlDecimalToQv(invalid); err == nil {
+			t.Errorf("PerlDecimalToQv(%q) => nil error, expected error",
+				invalid)
+		}
+	}
+}
+
+func TestPerlQvToDecimal(t *testing.T) {
+	tests := []struct {
+		qv      string
+		decimal string
+	}{
+		{"v5.34.0", "5.034000"},
+		{"5.8.9", "5.008009"},
+		{"v5.10.1", "5.010001"},
+		{"v5.36", "5.036000"},
+		{"5.010001", "5.010001"},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.qv)
+		actual, err := PerlQvToDecimal(&pv)
+		if err != nil {
+			t.Errorf("PerlQvToDecimal(%q) returned error: %v", test.qv,
+				err)
+			continue
+		}
+		if actual != test.decimal {
+			t.Errorf("PerlQvToDecimal(%q) => %q, expected %q", test.qv,
+				actual, test.decimal)
+		}
+		// and back again
+		back, err := PerlDecimalToQv(test.decimal)
+		if err != nil {
+			t.Errorf("PerlDecimalToQv(%q) returned error: %v",
+				test.decimal, err)
+			continue
+		}
+		if !back.Equal(&pv) {
+			t.Errorf("PerlDecimalToQv(%q) => %q, expected %q",
+				test.decimal, back.Raw(), pv.Normal())
+		}
+	}
+	for _, invalid := range []string{"v5.1000.0", "v5.34.1000", "v5.36.0.1",
+		"5.035_001", "v5.35.1_1"} {
+		pv := MustParse(invalid)
+		if actual, err := PerlQvToDecimal(&pv); err == nil {
+			t.Errorf("PerlQvToDecimal(%q) => %q, expected error", invalid,
+				actual)
+		}
+	}
+}
+
+func TestIsValidModuleVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+		reason   string // a substring of the reason
+	}{
+		{"1.002", true, ""},
+		{"0.01", true, ""},
+		{"2", true, ""},
+		{"v1.2.3", true, ""},
+		{"v5.36.0.1", true, ""},
+		{"", false, "empty"},
+		{"undef", false, "undef"},
+		{"foo", false, "invalid version"},
+		{"1.02_03", false, "alpha"},
+		{"v1.2.3_4", false, "alpha"},
+		{"v1.2", false, "too few components"},
+		{"01.2", false, "leading zero"},
+		{"1.", false, "only valid as a lax version"},
+		{".1", false, "only valid as a lax version"},
+		{"1.2.3", false, "only valid as a lax version"},
+		{"0 but true", false, "invalid version string"},
+	}
+	for _, test := range tests {
+		actual, reason := IsValidModuleVersion(test.version)
+		if actual != test.expected {
+			t.Errorf("IsValidModuleVersion(%q) => %t, expected %t "+
+				"(%s)", test.version, actual, test.expected, reason)
+		}
+		if !strings.Contains(reason, test.reason) ||
+			(test.expected && reason != "") {
+			t.Errorf("IsValidModuleVersion(%q) reason => %q, "+
+				"expected it to mention %q", test.version, reason,
+				test.reason)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+package perl_version
+
+// Reading and writing lists of versions, one per line.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseReader parses a newline-delimited list of versions from r, one line at
+// a time, so the list never has to be held in memory. fn is called with each
+// version in turn, or with the error from parsing it, which notes the line
+// number. Surrounding whitespace is ignored, and blank lines are skipped. The
+// returned error is only for failures reading r, including lines too long to
+// be a version.
+func ParseReader(r io.Reader, fn func(Version, error)) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		pv, err := Parse(text)
+		if err != nil {
+			err = fmt.Errorf("line %d: %w", line, err)
+		}
+		fn(pv, err)
+	}
+	return scanner.Err()
+}
+
+// WriteNormalized writes each version's Normal form to w, one per line, for
+// emitting a canonical list of versions. It stops at, and returns, the first
+// error from w.
+func WriteNormalized(w io.Writer, vs []Version) error {
+	for i := range vs {
+		if _, err := io.WriteString(w, vs[i].Normal()+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
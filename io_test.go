@@ -0,0 +1,113 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+package perl_version
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseReader(t *testing.T) {
+	input := "v1.2.3\n\n  1.02  \n1.2_3_4\r\nundef\r\n\t\n" +
+		"not a version\nv5.36.0"
+	var parsed []string
+	var failed []string
+	err := ParseReader(strings.NewReader(input), func(pv Version,
+		err error) {
+		if err != nil {
+			failed = append(failed, err.Error())
+			return
+		}
+		parsed = append(parsed, pv.Raw())
+	})
+	if err != nil {
+		t.Fatalf("ParseReader() returned error: %v", err)
+	}
+	expected := []string{"v1.2.3", "1.02", "undef", "v5.36.0"}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("ParseReader() parsed %q, expected %q", parsed, expected)
+	}
+	if len(failed) != 2 || !strings.HasPrefix(failed[0], "line 4: ") ||
+		!strings.HasPrefix(failed[1], "line 7: ") {
+		t.Errorf("ParseReader() failed with %q, expected lines 4 and 7",
+			failed)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestParseReaderError(t *testing.T) {
+	called := false
+	err := ParseReader(failingReader{}, func(Version, error) {
+		called = true
+	})
+	if err == nil || err.Error() != "read failed" {
+		t.Errorf("ParseReader() => %v, expected %q", err, "read failed")
+	}
+	if called {
+		t.Errorf("ParseReader() called fn on a failed read")
+	}
+}
+
+func TestWriteNormalized(t *testing.T) {
+	vs := []Version{MustParse("1.2"), MustParse("v1.2.3"),
+		MustParse("1.002003"), MustParse("undef"), MustParse("v1.2.3_4")}
+	var buf bytes.Buffer
+	if err := WriteNormalized(&buf, vs); err != nil {
+		t.Fatalf("WriteNormalized() returned error: %v", err)
+	}
+	expected := "v1.200.0\nv1.2.3\nv1.2.3\nv0.0.0\nv1.2.34\n"
+	if buf.String() != expected {
+		t.Errorf("WriteNormalized() wrote %q, expected %q", buf.String(),
+			expected)
+	}
+
+	buf.Reset()
+	if err := WriteNormalized(&buf, nil); err != nil || buf.Len() != 0 {
+		t.Errorf("WriteNormalized(nil) => %v, wrote %q, expected nothing",
+			err, buf.String())
+	}
+}
+
+// shortWriter accepts a limited number of bytes, then fails.
+type shortWriter struct {
+	remaining int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.remaining {
+		n := w.remaining
+		w.remaining = 0
+		return n, io.ErrShortWrite
+	}
+	w.remaining -= len(p)
+	return len(p), nil
+}
+
+func TestWriteNormalizedError(t *testing.T) {
+	vs := []Version{MustParse("v1.2.3"), MustParse("v4.5.6")}
+	err := WriteNormalized(&shortWriter{remaining: 10}, vs)
+	if !errors.Is(err, io.ErrShortWrite) {
+		t.Errorf("WriteNormalized() => %v, expected %v", err,
+			io.ErrShortWrite)
+	}
+}
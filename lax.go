@@ -19,14 +19,24 @@ package perl_version
 // deal of modules on CPAN that use it.
 
 import (
+	"errors"
 	"regexp"
 	"strings"
 )
 
 var (
-	laxRegexp = regexp.MustCompile(LaxVersionRegex)
+	laxRegexp       = regexp.MustCompile(LaxVersionRegex)
+	laxSearchRegexp = regexp.MustCompile(LaxVersionRegexUnanchored)
 )
 
+// LaxRegexp returns the compiled LaxVersionRegex, with leftmost-longest
+// matching already applied, as used by Parse. It's shared, which is fine since
+// a regexp.Regexp is safe for concurrent use. The regex is only anchored at the
+// end, so check the match starts at the beginning if you're validating.
+func LaxRegexp() *regexp.Regexp {
+	return laxRegexp
+}
+
 type laxDotted struct {
 	integer           string // version A
 	dottedGroup       string
@@ -42,6 +52,7 @@ type laxDecimal struct {
 	alpha          string
 	secondFraction string // version B
 	secondAlpha    string
+	width          int // digits per fraction component
 }
 
 type lax struct {
@@ -53,23 +64,33 @@ type lax struct {
 	decimalMatches laxDecimal
 }
 
-func (d laxDotted) toPerlVersionA(original string) Version {
+func (d laxDotted) toPerlVersionA(original string) (Version, error) {
 	dotted := d.dottedGroup
 	isAlpha := d.alpha != ""
 	if isAlpha {
+		// like Perl, the underscore is just dropped, so the alpha digits
+		// join the last component: "v1.2.3_45" is {1, 2, 345}
 		dotted += strings.TrimPrefix(d.alpha, "_")
 	}
 	var minors []int64
 	if dotted != "" {
-		minors = dottedToMinors(dotted)
+		var err error
+		minors, err = dottedToMinors(dotted)
+		if err != nil {
+			return Version{}, err
+		}
 	}
-	numValues := len(minors)
+	numValues := len(minors) + 1 // the integer part
 	if numValues < 3 {
 		// implied zeroes in v-qualified lax version
 		numValues = 3
 	}
 	values := make([]int64, numValues)
-	values[0] = mustParseInt64(d.integer)
+	integer, err := parseInt64(d.integer)
+	if err != nil {
+		return Version{}, err
+	}
+	values[0] = integer
 	if minors != nil {
 		copy(values[1:], minors)
 	}
@@ -78,19 +99,23 @@ func (d laxDotted) toPerlVersionA(original string) Version {
 		alpha:    isAlpha,
 		qv:       true,
 		version:  values,
-	}
+	}, nil
 }
 
-func (d laxDotted) toPerlVersionB(original string) Version {
-	// This particular case is a bit tricky. If there's three values,
-	// *implied* zeroes included, it counts as a quoted lax version.
+func (d laxDotted) toPerlVersionB(original string) (Version, error) {
+	// Without the leading v, it's the two or more dots that make this a
+	// dotted-decimal version, so like Perl, it's always qv, no matter how
+	// many components there are; "1.2.3.4" and ".1.2.3" included.
 
 	dotted := d.secondDottedGroup
 	isAlpha := d.secondAlpha != ""
 	if isAlpha {
 		dotted += strings.TrimPrefix(d.secondAlpha, "_")
 	}
-	minors := dottedToMinors(dotted)
+	minors, err := dottedToMinors(dotted)
+	if err != nil {
+		return Version{}, err
+	}
 	numValues := len(minors)
 	impliedZero := d.secondDottedGroup[0] == '.' && d.secondInteger == ""
 	if d.secondInteger != "" || impliedZero {
@@ -98,7 +123,10 @@ func (d laxDotted) toPerlVersionB(original string) Version {
 	}
 	values := make([]int64, numValues)
 	if d.secondInteger != "" {
-		values[0] = mustParseInt64(d.secondInteger)
+		values[0], err = parseInt64(d.secondInteger)
+		if err != nil {
+			return Version{}, err
+		}
 	} else if impliedZero {
 		values[0] = 0
 	}
@@ -109,18 +137,18 @@ func (d laxDotted) toPerlVersionB(original string) Version {
 	return Version{
 		original: original,
 		alpha:    d.secondAlpha != "",
-		qv:       numValues == 3,
+		qv:       true,
 		version:  values,
-	}
+	}, nil
 }
 
-func (d laxDotted) toPerlVersion(original string) Version {
+func (d laxDotted) toPerlVersion(original string) (Version, error) {
 	if d.integer != "" {
 		return d.toPerlVersionA(original)
 	} else if d.secondDottedGroup != "" {
 		return d.toPerlVersionB(original)
 	} else {
-		panic("unreachable")
+		return Version{}, errUnrecognizedForm
 	}
 }
 
@@ -139,14 +167,21 @@ func (d laxDecimal) toPerlVersionA(original string) (Version, error) {
 		}
 		fractionStr += strings.TrimPrefix(d.alpha, "_")
 	}
-	fractions := getFractionValue(fractionStr)
+	fractions, err := getFractionValue(fractionStr, d.width)
+	if err != nil {
+		return Version{}, err
+	}
 	numValues := len(fractions) + 1
 	impliedZeroEnd := original[len(original)-1] == '.' && d.fraction == ""
 	if impliedZeroEnd {
 		numValues++
 	}
 	values := make([]int64, numValues)
-	values[0] = mustParseInt64(d.integer)
+	integer, err := parseInt64(d.integer)
+	if err != nil {
+		return Version{}, err
+	}
+	values[0] = integer
 	if fractions != nil {
 		copy(values[1:], fractions)
 	}
@@ -161,15 +196,18 @@ func (d laxDecimal) toPerlVersionA(original string) (Version, error) {
 	}, nil
 }
 
-func (d laxDecimal) toPerlVersionB(original string) Version {
+func (d laxDecimal) toPerlVersionB(original string) (Version, error) {
 	fractionStr := d.secondFraction
 	isAlpha := d.secondAlpha != ""
 	if isAlpha {
 		fractionStr += strings.TrimPrefix(d.secondAlpha, "_")
 	}
-	fractions := getFractionValue(fractionStr)
+	fractions, err := getFractionValue(fractionStr, d.width)
+	if err != nil {
+		return Version{}, err
+	}
 	if fractions == nil {
-		panic("unreachable")
+		return Version{}, errUnrecognizedForm
 	}
 	values := make([]int64, len(fractions)+1) // implied zero
 	values[0] = 0
@@ -179,16 +217,16 @@ func (d laxDecimal) toPerlVersionB(original string) Version {
 		alpha:    d.secondAlpha != "",
 		qv:       false,
 		version:  values,
-	}
+	}, nil
 }
 
 func (d laxDecimal) toPerlVersion(original string) (Version, error) {
 	if d.integer != "" {
 		return d.toPerlVersionA(original)
 	} else if d.secondFraction != "" {
-		return d.toPerlVersionB(original), nil
+		return d.toPerlVersionB(original)
 	} else {
-		panic("unreachable")
+		return Version{}, errUnrecognizedForm
 	}
 }
 
@@ -201,15 +239,58 @@ func (d lax) toPerlVersion() (Version, error) {
 			version:  []int64{0},
 		}, nil
 	} else if d.dotted != "" {
-		return d.dottedMatches.toPerlVersion(d.original), nil
+		return d.dottedMatches.toPerlVersion(d.original)
 	} else if d.decimal != "" {
 		return d.decimalMatches.toPerlVersion(d.original)
 	} else {
-		panic("unreachable")
+		return Version{}, errUnrecognizedForm
+	}
+}
+
+// form names which branch of the lax grammar matched, see LaxForm.
+func (d lax) form() string {
+	switch {
+	case d.undef != "":
+		return "undef"
+	case d.dottedMatches.integer != "":
+		return "dotted A"
+	case d.dottedMatches.secondDottedGroup != "":
+		return "dotted B"
+	case d.decimalMatches.integer != "":
+		return "decimal A"
+	default:
+		return "decimal B"
 	}
 }
 
-func laxVersion(matches []string) (Version, error) {
+// LaxForm reports which form of the lax grammar a version string matches, for
+// working out how Parse reads an edge case. The forms are:
+//
+//   - "dotted A": a leading v, e.g. "v1.2" or "v1.2.3_4"
+//   - "dotted B": two or more dots without a v, e.g. "1.2.3" or ".1.2"
+//   - "decimal A": an integer with an optional fraction, e.g. "1.2_3" or "1."
+//   - "decimal B": just a fraction, e.g. ".1"
+//
+// The literal "undef" gives "undef". Strings that aren't valid lax versions,
+// including ones the grammar matches but Parse still rejects, like "1_0", give
+// an error.
+func LaxForm(version string) (string, error) {
+	matches := laxRegexp.FindStringSubmatch(version)
+	if matches == nil || matches[0] != version {
+		return "", errors.New("invalid lax version string: " + version)
+	}
+	d := newLax(matches, 3)
+	if _, err := d.toPerlVersion(); err != nil {
+		return "", err
+	}
+	return d.form(), nil
+}
+
+func laxVersion(matches []string, width int) (Version, error) {
+	return newLax(matches, width).toPerlVersion()
+}
+
+func newLax(matches []string, width int) lax {
 	return lax{
 		original: matches[0],
 		undef:    matches[1],
@@ -229,6 +310,7 @@ func laxVersion(matches []string) (Version, error) {
 			alpha:          matches[12],
 			secondFraction: matches[13],
 			secondAlpha:    matches[14],
+			width:          width,
 		},
-	}.toPerlVersion()
+	}
 }
@@ -0,0 +1,145 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package perl_version
+
+// Options for Parse. Without any, Parse follows version.pm as closely as
+// possible; these are for when that's not what you want.
+
+// Option changes how Parse behaves.
+type Option func(*parseOptions)
+
+type parseOptions struct {
+	uppercaseV    bool
+	grammar       grammar
+	alphaPolicy   AlphaPolicy
+	emptyAsUndef  bool
+	scientific    bool
+	fractionWidth int
+	trimSpace     bool
+	zeroButTrue   bool
+}
+
+// grammar is which of the grammars Parse is allowed to use.
+type grammar int
+
+const (
+	anyGrammar grammar = iota
+	strictGrammar
+	laxGrammar
+)
+
+// AlphaPolicy is what Parse does with alpha versions, see WithAlphaPolicy.
+type AlphaPolicy int
+
+const (
+	// AlphaAllow parses alpha versions like any other. This is the
+	// default.
+	AlphaAllow AlphaPolicy = iota
+	// AlphaReject makes alpha versions a parse error.
+	AlphaReject
+)
+
+func newParseOptions(opts []Option) *parseOptions {
+	o := &parseOptions{fractionWidth: 3}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithUppercaseV accepts a leading uppercase "V" as if it were a lowercase
+// "v", so "V1.2.3" parses the same as "v1.2.3". Perl's grammar only allows the
+// lowercase form, but some tooling emits the uppercase one.
+func WithUppercaseV() Option {
+	return func(o *parseOptions) {
+		o.uppercaseV = true
+	}
+}
+
+// WithTrimSpace strips leading and trailing whitespace before parsing, so
+// " v1.2.3 " parses as "v1.2.3", with the trimmed string as the original.
+// version.pm does the same, but the regexes don't allow for it, so it's opt-in.
+// Whitespace alone counts as an empty version.
+func WithTrimSpace() Option {
+	return func(o *parseOptions) {
+		o.trimSpace = true
+	}
+}
+
+// WithZeroButTrue accepts Perl's "0 but true" and "0E0" (in either case),
+// which some CPAN metadata uses for "no version required", as zero versions
+// that are still IsTrue. version.pm rejects both as non-numeric, so it's
+// opt-in, and neither WithStrictOnly nor WithLaxOnly will take them, since
+// they aren't in either grammar.
+func WithZeroButTrue() Option {
+	return func(o *parseOptions) {
+		o.zeroButTrue = true
+	}
+}
+
+// WithStrictOnly only accepts versions matching the strict grammar, see
+// ParseStrict. This overrides an earlier WithLaxOnly.
+func WithStrictOnly() Option {
+	return func(o *parseOptions) {
+		o.grammar = strictGrammar
+	}
+}
+
+// WithLaxOnly only applies the lax grammar, see ParseLax. This overrides an
+// earlier WithStrictOnly.
+func WithLaxOnly() Option {
+	return func(o *parseOptions) {
+		o.grammar = laxGrammar
+	}
+}
+
+// WithAlphaPolicy sets how alpha versions are handled, e.g. AlphaReject to
+// refuse development releases outright.
+func WithAlphaPolicy(policy AlphaPolicy) Option {
+	return func(o *parseOptions) {
+		o.alphaPolicy = policy
+	}
+}
+
+// WithEmptyAsUndef parses the empty string as Undef, where Parse would
+// otherwise return ErrEmptyVersion. Perl treats an empty version much like an
+// undefined one, so this is handy for optional fields.
+func WithEmptyAsUndef() Option {
+	return func(o *parseOptions) {
+		o.emptyAsUndef = true
+	}
+}
+
+// WithScientificNotation accepts decimal versions written in scientific
+// notation, as some older tooling emits, by rewriting them as plain decimals
+// before parsing: "5.034E0" parses as "5.034", and "1.2e1" as "12". This
+// deviates from version.pm, which rejects them, so it's opt-in. Alpha
+// versions can't be written this way.
+func WithScientificNotation() Option {
+	return func(o *parseOptions) {
+		o.scientific = true
+	}
+}
+
+// WithFractionWidth sets how many digits of a decimal version's fraction go
+// into each component. Perl always uses three, so "1.0203" is {1, 20, 300};
+// with a width of 2 it's {1, 2, 3}. Only parsing is affected: the other
+// methods, such as Numify, still assume three digits. The width has to be
+// between 1 and 18, or Parse returns an error.
+func WithFractionWidth(width int) Option {
+	return func(o *parseOptions) {
+		o.fractionWidth = width
+	}
+}
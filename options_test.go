@@ -0,0 +1,259 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+package perl_version
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithUppercaseV(t *testing.T) {
+	for _, version := range []string{"V1.2.3", "V1", "V1.2_3"} {
+		if _, err := Parse(version); err == nil {
+			t.Errorf("Parse(%q) => nil error, expected error",
+				version)
+		}
+		pv, err := Parse(version, WithUppercaseV())
+		if err != nil {
+			t.Errorf("Parse(%q, WithUppercaseV()) returned error: %v",
+				version, err)
+			continue
+		}
+		expected := MustParse("v" + version[1:])
+		if !reflect.DeepEqual(pv, expected) {
+			t.Errorf("Parse(%q, WithUppercaseV()) => %+v, expected "+
+				"%+v", version, pv, expected)
+		}
+	}
+
+	// only the leading V is affected
+	for _, version := range []string{"1.2.3V", "VV1.2.3", "V"} {
+		if _, err := Parse(version, WithUppercaseV()); err == nil {
+			t.Errorf("Parse(%q, WithUppercaseV()) => nil error, "+
+				"expected error", version)
+		}
+	}
+}
+
+func TestWithTrimSpace(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{" v1.2.3", "v1.2.3"},
+		{"v1.2.3 ", "v1.2.3"},
+		{" v1.2.3 ", "v1.2.3"},
+		{"\t1.02_03\n", "1.02_03"},
+		{"  undef  ", "undef"},
+		{"1.2", "1.2"},
+	}
+	for _, test := range tests {
+		if _, err := Parse(test.version); err == nil &&
+			test.version != test.expected {
+			t.Errorf("Parse(%q) => nil error, expected error",
+				test.version)
+		}
+		pv, err := Parse(test.version, WithTrimSpace())
+		if err != nil {
+			t.Errorf("Parse(%q, WithTrimSpace()) returned error: %v",
+				test.version, err)
+			continue
+		}
+		expected := MustParse(test.expected)
+		if !reflect.DeepEqual(pv, expected) {
+			t.Errorf("Parse(%q, WithTrimSpace()) => %+v, expected "+
+				"%+v", test.version, pv, expected)
+		}
+	}
+
+	// only surrounding whitespace goes
+	if _, err := Parse("v1. 2", WithTrimSpace()); err == nil {
+		t.Errorf("Parse(%q, WithTrimSpace()) => nil error, expected "+
+			"error", "v1. 2")
+	}
+	_, err := Parse("  ", WithTrimSpace())
+	if !errors.Is(err, ErrEmptyVersion) {
+		t.Errorf("Parse(%q, WithTrimSpace()) => %v, expected %v", "  ",
+			err, ErrEmptyVersion)
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		version string
+		opts    []Option
+		valid   bool
+	}{
+		{"v1.2.3", nil, true},
+		{"v1.2", nil, true},
+		{"1.2_3", nil, true},
+		{"v1.2.3", []Option{WithStrictOnly()}, true},
+		{"v1.2", []Option{WithStrictOnly()}, false},
+		{"undef", []Option{WithStrictOnly()}, false},
+		{"v1.2", []Option{WithLaxOnly()}, true},
+		{"1.2_3", []Option{WithLaxOnly()}, true},
+		{"foo", []Option{WithLaxOnly()}, false},
+		// the last grammar option wins
+		{"v1.2", []Option{WithLaxOnly(), WithStrictOnly()}, false},
+		{"v1.2", []Option{WithStrictOnly(), WithLaxOnly()}, true},
+		{"1.2_3", []Option{WithAlphaPolicy(AlphaAllow)}, true},
+		{"1.2_3", []Option{WithAlphaPolicy(AlphaReject)}, false},
+		{"1.23", []Option{WithAlphaPolicy(AlphaReject)}, true},
+		{"V1.2_3", []Option{WithUppercaseV(),
+			WithAlphaPolicy(AlphaReject)}, false},
+		{"V1.2.3", []Option{WithUppercaseV(), WithStrictOnly()}, true},
+	}
+	for _, test := range tests {
+		pv, err := Parse(test.version, test.opts...)
+		if (err == nil) != test.valid {
+			t.Errorf("Parse(%q, %d options) => %v, expected valid: "+
+				"%t", test.version, len(test.opts), err,
+				test.valid)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		// options only decide validity, not the result
+		expected, err := Parse(test.version, WithUppercaseV())
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", test.version,
+				err)
+		}
+		if !reflect.DeepEqual(pv, expected) {
+			t.Errorf("Parse(%q, %d options) => %+v, expected %+v",
+				test.version, len(test.opts), pv, expected)
+		}
+	}
+}
+
+func TestEmptyVersion(t *testing.T) {
+	_, err := Parse("")
+	if !errors.Is(err, ErrEmptyVersion) {
+		t.Errorf("Parse(\"\") => %v, expected %v", err, ErrEmptyVersion)
+	}
+	_, err = ParseStrict("")
+	if !errors.Is(err, ErrEmptyVersion) {
+		t.Errorf("ParseStrict(\"\") => %v, expected %v", err,
+			ErrEmptyVersion)
+	}
+
+	pv, err := Parse("", WithEmptyAsUndef())
+	if err != nil {
+		t.Fatalf("Parse(\"\", WithEmptyAsUndef()) returned error: %v", err)
+	}
+	if !pv.IsUndef() || pv.Raw() != "undef" {
+		t.Errorf("Parse(\"\", WithEmptyAsUndef()) => %q, expected undef",
+			pv.Raw())
+	}
+}
+
+func TestWithScientificNotation(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"1.2e1", "12"},
+		{"1.2E1", "12"},
+		{"5.034E0", "5.034"},
+		{"5.034000e+0", "5.034000"},
+		{"1e3", "1000"},
+		{"1.e1", "10"},
+		{"12.5e-1", "1.25"},
+		{"1.5e-3", "0.0015"},
+		{"0.05e2", "5"},
+		{"123.456e2", "12345.6"},
+	}
+	for _, test := range tests {
+		pv, err := Parse(test.version, WithScientificNotation())
+		if err != nil {
+			t.Errorf("Parse(%q, WithScientificNotation()) returned "+
+				"error: %v", test.version, err)
+			continue
+		}
+		expected := MustParse(test.expected)
+		if !reflect.DeepEqual(pv, expected) {
+			t.Errorf("Parse(%q, WithScientificNotation()) => %#v, "+
+				"expected %#v", test.version, pv, expected)
+		}
+		if _, err := Parse(test.version); err == nil {
+			t.Errorf("Parse(%q) => nil error, expected error",
+				test.version)
+		}
+	}
+
+	invalid := []string{"1.2e", "e1", "1.2_3e1", "v1.2e1", "1.2e99999"}
+	for _, version := range invalid {
+		if _, err := Parse(version, WithScientificNotation()); err == nil {
+			t.Errorf("Parse(%q, WithScientificNotation()) => nil "+
+				"error, expected error", version)
+		}
+	}
+}
+
+func TestWithFractionWidth(t *testing.T) {
+	tests := []struct {
+		version  string
+		width    int
+		expected []int64
+	}{
+		{"1.0203", 3, []int64{1, 20, 300}},
+		{"1.0203", 2, []int64{1, 2, 3}},
+		{"1.0203", 4, []int64{1, 203}},
+		{"1.02035", 2, []int64{1, 2, 3, 50}},
+		{"1.02035", 4, []int64{1, 203, 5000}},
+		{"1.2_3", 2, []int64{1, 23}},
+		{".1", 4, []int64{0, 1000}},
+		{"1.", 2, []int64{1, 0}},
+		{"1.123456", 1, []int64{1, 1, 2, 3, 4, 5, 6}},
+		// dotted versions aren't affected
+		{"v1.02.03", 2, []int64{1, 2, 3}},
+		{"1.2.3", 4, []int64{1, 2, 3}},
+	}
+	for _, test := range tests {
+		pv, err := Parse(test.version, WithFractionWidth(test.width))
+		if err != nil {
+			t.Errorf("Parse(%q, WithFractionWidth(%d)) returned error: "+
+				"%v", test.version, test.width, err)
+			continue
+		}
+		if !reflect.DeepEqual(pv.version, test.expected) {
+			t.Errorf("Parse(%q, WithFractionWidth(%d)) => %v, expected "+
+				"%v", test.version, test.width, pv.version,
+				test.expected)
+		}
+	}
+
+	// the default is Perl's
+	for _, version := range []string{"1.0203", "1.2_3", "0.000001"} {
+		pv, err := Parse(version, WithFractionWidth(3))
+		if err != nil {
+			t.Fatalf("Parse(%q, WithFractionWidth(3)) returned error: "+
+				"%v", version, err)
+		}
+		if expected := MustParse(version); !reflect.DeepEqual(pv,
+			expected) {
+			t.Errorf("Parse(%q, WithFractionWidth(3)) => %#v, expected "+
+				"%#v", version, pv, expected)
+		}
+	}
+
+	for _, width := range []int{0, -1, 19} {
+		if _, err := Parse("1.2", WithFractionWidth(width)); err == nil {
+			t.Errorf("Parse(%q, WithFractionWidth(%d)) => nil error, "+
+				"expected error", "1.2", width)
+		}
+	}
+}
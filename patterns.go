@@ -51,16 +51,25 @@ const (
 		`?)?|` + laxIntR + `?` + laxDotted2PR + laxAlphaR + `?)`
 )
 
+// LaxVersionRegexUnanchored is LaxVersionRegex without its trailing anchor, for
+// embedding in larger patterns or finding versions inside other text. The
+// match itself has no boundaries, so "v1.2" will match inside "xv1.2y".
+const LaxVersionRegexUnanchored = `(?:` + laxUndefR + `|` + laxDottedFormR +
+	`|` + laxDecimalFormR + `)`
+
+// StrictVersionRegexUnanchored is StrictVersionRegex without its trailing
+// anchor, in the same way as LaxVersionRegexUnanchored.
+const StrictVersionRegexUnanchored = `(?:` + strictDecimalFormR + `|` +
+	strictDottedFormR + `)`
+
 // LaxVersionRegex is a regular expression that matches a Perl version string,
 // under the documented rules under version::regexp. It is a direct adaptation
 // to Go's regex-engine. The Lax version has a few interesting edge cases, but
 // so there's actually four different forms it has to cover.
-const LaxVersionRegex = `(?:` + laxUndefR + `|` + laxDottedFormR + `|` +
-	laxDecimalFormR + `)$`
+const LaxVersionRegex = LaxVersionRegexUnanchored + `$`
 
 // StrictVersionRegex is a regular expression that matches a Perl version
 // string,under the documented rules under version::regexp. Strict versioning
 // is highly recommended, both by the Perl project and someone who's just
 // had to write a parser for the lax version.
-const StrictVersionRegex = `(?:` + strictDecimalFormR + `|` +
-	strictDottedFormR + `)$`
+const StrictVersionRegex = StrictVersionRegexUnanchored + `$`
@@ -19,8 +19,15 @@
 package perl_version
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"reflect"
 	"strconv"
 	"strings"
 )
@@ -28,6 +35,11 @@ import (
 // Version is a direct, mapping of Perl's version::Internal, methods and
 // all. It's meant to be opaque, as the internal representation might change
 // if the need arises.
+//
+// None of the methods modify a Version except Set and the Unmarshal/Decode
+// methods, so a Version can be shared between goroutines for reading and
+// comparing without any locking. Copies share their components, so use Clone
+// on any copy that has to be modified.
 type Version struct {
 	original string
 	alpha    bool
@@ -49,10 +61,42 @@ func (v *Version) IsAlpha() bool {
 	return v.alpha
 }
 
+// IsUndef checks whether a version is undefined, i.e. parsed from "undef" or
+// created with Undef. The zero-value Version counts as undefined too, much
+// like an uninitialized scalar in Perl.
+func (v *Version) IsUndef() bool {
+	if v.original == "" && len(v.version) == 0 {
+		return true
+	}
+	return v.original == "undef"
+}
+
+// IsStable checks whether a version is a stable release, i.e. not an alpha
+// version. This is the inverse of IsAlpha.
+func (v *Version) IsStable() bool {
+	return !v.alpha
+}
+
+// IsTrue returns the version's truthiness in Perl's boolean context, where a
+// version is true unless it compares equal to 0. Undef, "0" and "v0.0.0" are
+// false, while "v0.0.1" is true, as are "0 but true" and "0E0", which are zero
+// but true by design.
+func (v *Version) IsTrue() bool {
+	if isZeroButTrue(v.original) {
+		return true
+	}
+	for _, component := range v.version {
+		if component != 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // IsQv checks whether a version is a qv version. This is indicated by a 'v' at
-// the beginning of the version. For example, "v1.2.3" is a qv version, while
-// "1.2.3" is not. The versions are not equal either- "1.2.3" is represented
-// as "v1.200.300", and "v1.2.3" is still just "v1.2.3".
+// the beginning of the version, or by two or more dots. For example, "v1.2"
+// and "1.2.3" are qv versions, while "1.2" is not. The versions "v1.2" and
+// "1.2" are not equal either- "1.2" is represented as "v1.200.0".
 func (v *Version) IsQv() bool {
 	return v.qv
 }
@@ -73,33 +117,251 @@ func (v *Version) Normal() string {
 	return "v" + strings.Join(asStrings, ".")
 }
 
+// NormalTrimmed is like Normal, but without padding to three components, so
+// "v1.2" gives "v1.2" rather than "v1.2.0", and "1.2" gives "v1.200". Only the
+// padding is left off; explicit zeros, as in "v1.2.0", are kept.
+func (v *Version) NormalTrimmed() string {
+	components := v.writtenComponents()
+	asStrings := make([]string, len(components))
+	for i, component := range components {
+		asStrings[i] = strconv.FormatInt(component, 10)
+	}
+	return "v" + strings.Join(asStrings, ".")
+}
+
+// writtenComponents returns the components without the padding the v-forms
+// get when parsed, i.e. as many as were written.
+func (v *Version) writtenComponents() []int64 {
+	components := v.components()
+	if v.qv {
+		components = components[:min(len(components),
+			strings.Count(v.original, ".")+1)]
+	}
+	return components
+}
+
+// ImpliedComponents returns how many of the components weren't written in the
+// original, but filled in with zeros when it was parsed. That's the padding to
+// three components for a v-string, so "v1" has two and "v1.2" one, and the
+// zero before a leading dot or after a trailing one, so ".1.2", ".1" and "1."
+// have one each. Versions written out in full, such as "v1.2.3" or "1.002",
+// have none, as do "undef" and the zero Version.
+func (v *Version) ImpliedComponents() int {
+	if v.IsUndef() || v.original == "" {
+		return 0
+	}
+	implied := 0
+	if v.qv {
+		written := strings.Count(v.original, ".") + 1
+		if strings.HasPrefix(v.original, ".") {
+			written--
+		}
+		implied = len(v.version) - written
+	} else if len(v.version) > 1 {
+		if strings.HasPrefix(v.original, ".") {
+			implied++
+		}
+		if strings.HasSuffix(v.original, ".") {
+			implied++
+		}
+	}
+	return max(implied, 0)
+}
+
+// StableRelease returns the stable version with the same components as an
+// alpha version. Perl drops the underscore when parsing, so an alpha version
+// already has the numeric value of a stable one, and that's the one returned:
+// "v1.2.3_0" gives "v1.2.30", and "1.2345_01" gives "1.234501". Since any
+// components can be written without an underscore, there's never a need to
+// move on to a following release. The original is rewritten in the same form,
+// dotted or decimal, as the alpha version's. A stable version is returned as
+// a copy of itself.
+func (v *Version) StableRelease() Version {
+	if !v.alpha {
+		return v.Clone()
+	}
+	stable := v.Clone()
+	stable.alpha = false
+	if v.qv {
+		stable.original = v.NormalTrimmed()
+	} else {
+		stable.original = v.NumifyString()
+	}
+	return stable
+}
+
+// BumpAlpha returns the next development release after the version. For an
+// alpha version, the digits after the underscore are incremented, so
+// "v1.2.3_1" gives "v1.2.3_2" and "1.002_09" gives "1.002_10". A stable
+// version starts a new alpha series at "_1", so "v1.2.3" gives "v1.2.3_1";
+// if the original can't take an underscore as written (like "1" or "undef"),
+// its normal or numified form is used instead. Since Perl merges the alpha
+// digits into the preceding ones, a decimal alpha group that's run out of
+// digits, like "1.002_99", has a "1" appended rather than carrying over, as
+// "1.002_100" would sort before it. Either way, the result is always greater
+// than the receiver.
+func (v *Version) BumpAlpha() Version {
+	if !v.alpha {
+		bumped, err := Parse(v.original + "_1")
+		if err == nil {
+			return bumped
+		}
+		if v.qv {
+			return MustParse(v.Normal() + "_1")
+		}
+		return MustParse(v.NumifyString() + "_1")
+	}
+	i := strings.LastIndexByte(v.original, '_')
+	prefix, digits := v.original[:i+1], v.original[i+1:]
+	n, _ := strconv.ParseUint(digits, 10, 64)
+	next := strconv.FormatUint(n+1, 10)
+	switch {
+	case len(next) < len(digits):
+		next = strings.Repeat("0", len(digits)-len(next)) + next
+	case len(next) > len(digits) && !v.qv:
+		next = digits + "1"
+	}
+	return MustParse(prefix + next)
+}
+
+// Canonical returns a copy of the version in normal form: its original is
+// Normal(), it's a qv version, and it has at least three components. The
+// result is the same as parsing Normal(), which means the alpha flag is
+// dropped, since the normal form has no way to express it. Comparisons other
+// than CompareStable's alpha tie-break are unaffected.
+func (v *Version) Canonical() Version {
+	num := max(len(v.version), 3)
+	components := make([]int64, num)
+	copy(components, v.version)
+	return Version{
+		original: v.Normal(),
+		alpha:    false,
+		qv:       true,
+		version:  components,
+	}
+}
+
+// Trimmed returns the version with any trailing zero components removed from
+// its original, though the first component is always kept. This gives the
+// shortest equivalent form for display, e.g. "v1.2.0.0" becomes "v1.2", and
+// "1.200000" becomes "1.200", in the dotted form for qv versions and the
+// decimal form otherwise. The result is what parsing that original gives, so
+// a qv version is still padded to three components, and since neither form
+// spells out an alpha part, the alpha flag is dropped, as with Canonical. It's
+// Equal to the receiver either way.
+func (v *Version) Trimmed() Version {
+	components := v.components()[:v.SignificantComponents()]
+	var original string
+	if v.qv {
+		asStrings := make([]string, len(components))
+		for i, component := range components {
+			asStrings[i] = strconv.FormatInt(component, 10)
+		}
+		original = "v" + strings.Join(asStrings, ".")
+	} else if len(components) == 1 {
+		original = strconv.FormatInt(components[0], 10)
+	} else {
+		stable := Version{version: components}
+		original = stable.NumifyString()
+	}
+	trimmed, err := Parse(original)
+	if err != nil {
+		// only possible with negative components
+		return v.Clone()
+	}
+	return trimmed
+}
+
 // Numify returns the numeric version of a version string. For example,
 // "v1.2.3" would return 1.002003. This is useful for quick comparisons, and
 // embedding in maps, though if you have a version with many subversions, it's
 // probably better to use the relevant comparison methods (which are probably
-// faster regardless).
+// faster regardless). Be aware that this is lossy: a float64 only holds about
+// 15 significant digits, so something like "v1.2.3.4.5.6" can't be represented
+// exactly. Use NumifyString if you need the exact value. It's worked out from
+// the components directly, so calling it doesn't allocate.
 func (v *Version) Numify() float64 {
-	if len(v.version) == 1 {
-		return float64(v.version[0])
+	if len(v.version) <= 1 {
+		return float64(v.component(0))
+	}
+	// NumifyString's digits as an integer, and how many follow the point
+	digits, places := v.version[0], 0
+	for _, component := range v.version[1:] {
+		if component >= maxExactFloatInt {
+			return v.numifySlow()
+		}
+		// padded to three digits, like NumifyString
+		scale := int64(1000)
+		places += 3
+		for scale <= component {
+			scale *= 10
+			places++
+		}
+		if places > maxExactPowerOfTen ||
+			digits > (maxExactFloatInt-component)/scale {
+			return v.numifySlow()
+		}
+		digits = digits*scale + component
 	}
-	asStrings := make([]string, len(v.version)-1)
-	for i, v := range v.version[1:] {
-		asStrings[i] = strconv.FormatInt(v, 10)
+	// both are exact, so the division rounds once, as ParseFloat would
+	return float64(digits) / math.Pow10(places)
+}
+
+// numifySlow is Numify for versions whose digits don't fit in a float64
+// exactly, which goes through NumifyString instead.
+func (v *Version) numifySlow() float64 {
+	out, _ := strconv.ParseFloat(v.NumifyString(), 64)
+	return out
+}
+
+// Decimal returns the version in Perl's decimal form, the counterpart to the
+// qv form returned by Normal. "v1.2.3" would return "1.002003": each component
+// after the first is zero-padded to three digits, and nothing is trimmed, so
+// "v1.2.0" returns "1.002000". This is the same string as NumifyString.
+func (v *Version) Decimal() string {
+	return v.NumifyString()
+}
+
+// NumifyString returns the exact numeric version as a string, the same as
+// Perl's numify. For example, "v1.2.3" would return "1.002003", and "42"
+// would return "42.000".
+func (v *Version) NumifyString() string {
+	components := v.components()
+	var sb strings.Builder
+	sb.WriteString(strconv.FormatInt(components[0], 10))
+	sb.WriteByte('.')
+	if len(components) == 1 {
+		sb.WriteString("000")
+	}
+	for _, component := range components[1:] {
 		// pad with zeros
-		for len(asStrings[i]) < 3 {
-			asStrings[i] = "0" + asStrings[i]
+		digits := strconv.FormatInt(component, 10)
+		for i := len(digits); i < 3; i++ {
+			sb.WriteByte('0')
 		}
+		sb.WriteString(digits)
+	}
+	return sb.String()
+}
+
+// NumifyRat returns the exact numeric version as a rational, so it can be
+// compared without the rounding of Numify. Ordering by NumifyRat agrees with
+// Compare as long as every component after the first is below 1000, which is
+// the case for anything parsed from a decimal version.
+func (v *Version) NumifyRat() *big.Rat {
+	out, ok := new(big.Rat).SetString(v.NumifyString())
+	if !ok {
+		// NumifyString only ever returns a decimal number
+		panic("logic error: unparseable numify string")
 	}
-	tail := strings.Join(asStrings, "")
-	str := fmt.Sprintf("%d.%s", v.version[0], tail)
-	out, _ := strconv.ParseFloat(str, 64)
 	return out
 }
 
 // Stringify matches its Perl equivalent- functionally it acts the same as Raw,
-// however if the Version is undefined, it returns "0".
+// however if the Version is undefined (including the zero-value Version), it
+// returns "0".
 func (v *Version) Stringify() string {
-	if v.original == "undef" {
+	if v.IsUndef() {
 		return "0"
 	}
 	return v.original
@@ -110,9 +372,105 @@ func (v *Version) Raw() string {
 	return v.original
 }
 
-// MarshalJSON implements the json.Marshaler interface. This allows for caching
-// of the version.
-func (v *Version) MarshalJSON() ([]byte, error) {
+// String returns the original representation of the version, the same as
+// Raw. Along with Set, it makes *Version a flag.Value, so it's safe to call on
+// a nil receiver, which the flag package does when printing defaults.
+func (v *Version) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.original
+}
+
+// Format implements fmt.Formatter. %s prints the original string, and %q
+// quotes it, both taking the usual width and flags. %v prints Normal, %+v
+// follows it with the numeric form, as in "v1.2.3 (1.002003)", and %#v prints
+// a Go-syntax representation with the components and flags.
+func (v Version) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's', 'q':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), v.original)
+	case 'v':
+		switch {
+		case f.Flag('#'):
+			fmt.Fprintf(f, "perl_version.Version{original:%q, "+
+				"version:%#v, alpha:%t, qv:%t}", v.original,
+				v.version, v.alpha, v.qv)
+		case f.Flag('+'):
+			fmt.Fprintf(f, "%s (%s)", v.Normal(), v.NumifyString())
+		default:
+			fmt.Fprint(f, v.Normal())
+		}
+	default:
+		fmt.Fprintf(f, "%%!%c(perl_version.Version=%s)", verb, v.original)
+	}
+}
+
+// Set parses value into the version, for use as a flag.Value with flag.Var.
+// The version is left untouched if value doesn't parse.
+func (v *Version) Set(value string) error {
+	pv, err := Parse(value)
+	if err != nil {
+		return err
+	}
+	*v = pv
+	return nil
+}
+
+// Type returns "perlversion", which is what spf13/pflag shows in its help
+// output. Along with String and Set, it makes *Version a pflag.Value.
+func (v *Version) Type() string {
+	return "perlversion"
+}
+
+// Form is which string form of a version MarshalJSONAs encodes.
+type Form int
+
+const (
+	// FormStringify is Stringify, the original except that undef is
+	// "0". This is the default for MarshalJSON.
+	FormStringify Form = iota
+	// FormOriginal is the original string, as Raw returns it.
+	FormOriginal
+	// FormNormal is the normalized dotted form, as Normal returns it.
+	FormNormal
+)
+
+// JSONForm is the form MarshalJSON encodes versions in, for when e.g. API
+// responses should always carry Normal(). It's a plain variable, so it isn't
+// safe to change while versions are being marshaled; set it once at startup.
+var JSONForm = FormStringify
+
+// MarshalJSON implements the json.Marshaler interface. The version is encoded
+// as a bare JSON string, using Stringify by default, which is what most users
+// expect to see; see JSONForm. UnmarshalJSON parses it back to an equal
+// version, though since Stringify turns undef into "0", that particular case
+// comes back as "0". For caching, where the version needs to round-trip
+// exactly, use MarshalJSONVerbose. Like GobEncode, it has a value receiver,
+// so Versions in maps and non-pointer struct fields are encoded too.
+func (v Version) MarshalJSON() ([]byte, error) {
+	return v.MarshalJSONAs(JSONForm)
+}
+
+// MarshalJSONAs encodes the version as a bare JSON string in the given form,
+// regardless of JSONForm.
+func (v Version) MarshalJSONAs(form Form) ([]byte, error) {
+	switch form {
+	case FormStringify:
+		return json.Marshal(v.Stringify())
+	case FormOriginal:
+		return json.Marshal(v.original)
+	case FormNormal:
+		return json.Marshal(v.Normal())
+	default:
+		return nil, fmt.Errorf("unknown version form %d", form)
+	}
+}
+
+// MarshalJSONVerbose encodes the version as a JSON object holding all of its
+// internal state. This allows for caching of the version, as UnmarshalJSON
+// reproduces it exactly.
+func (v *Version) MarshalJSONVerbose() ([]byte, error) {
 	data := struct {
 		Original string  `json:"original"`
 		Alpha    bool    `json:"alpha"`
@@ -127,15 +485,124 @@ func (v *Version) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&data)
 }
 
+// Key returns a canonical string for the version, suitable for use as a map
+// key. It's the dotted form of the components with any trailing zeros removed,
+// followed by "-alpha" for alpha versions, so "v1.2", "v1.2.0" and "1.002"
+// all have the key "v1.2". Two versions have the same key exactly when
+// they're Equal and agree on IsAlpha.
+func (v *Version) Key() string {
+	length := v.SignificantComponents()
+	asStrings := make([]string, length)
+	for i, component := range v.components()[:length] {
+		asStrings[i] = strconv.FormatInt(component, 10)
+	}
+	key := "v" + strings.Join(asStrings, ".")
+	if v.alpha {
+		key += "-alpha"
+	}
+	return key
+}
+
+// Bytes returns the components as a stable byte string for hashing: each
+// component as a big-endian int64, with trailing zero components dropped
+// (keeping at least one). Versions with the same Normal form, like "v1.2" and
+// "v1.2.0", produce the same bytes. Unlike Key, the alpha flag isn't included.
+func (v *Version) Bytes() []byte {
+	length := v.SignificantComponents()
+	data := make([]byte, 0, 8*length)
+	for _, component := range v.components()[:length] {
+		data = binary.BigEndian.AppendUint64(data, uint64(component))
+	}
+	return data
+}
+
+// ValueKeyComponents is the number of components kept in a ValueKey.
+const ValueKeyComponents = 8
+
+// ValueKey is a comparable form of a Version, for using versions directly as
+// map keys without going through a string. Missing components are zero, so
+// like Key, two versions have the same ValueKey when they're Equal and agree
+// on IsAlpha. Components past the first ValueKeyComponents are dropped, so
+// versions that only differ after that will collide.
+type ValueKey struct {
+	Components [ValueKeyComponents]int64
+	Alpha      bool
+}
+
+// ValueKey returns the version's ValueKey.
+func (v *Version) ValueKey() ValueKey {
+	key := ValueKey{Alpha: v.alpha}
+	copy(key.Components[:], v.version)
+	return key
+}
+
+// NumComponents returns the number of components the version has, including
+// any zeros implied by the parser, so "v1" has three and "1.2" has two.
+func (v *Version) NumComponents() int {
+	return len(v.version)
+}
+
+// SignificantComponents returns the number of components once trailing zeros
+// are removed, though never less than one. So "v1.2.0" has two, as does
+// "v1.2", while "v0.0.0" and "undef" have one.
+func (v *Version) SignificantComponents() int {
+	length := len(v.version)
+	for length > 1 && v.version[length-1] == 0 {
+		length--
+	}
+	return max(length, 1)
+}
+
 // Version returns the version as a slice of integers.
 func (v *Version) Version() []int64 {
 	// return duplicate
 	return append([]int64{}, v.version...)
 }
 
+// Clone returns a deep copy of the version. Copying a Version by value shares
+// the underlying components, so use this when the copy needs to outlive or be
+// modified independently of the original.
+func (v *Version) Clone() Version {
+	clone := *v
+	if v.version != nil {
+		clone.version = append([]int64{}, v.version...)
+	}
+	return clone
+}
+
+// MustRoundTrip parses the version's original again, returning the result,
+// and panics unless it's Identical to the receiver. Anything from Parse
+// passes, as do the methods returning a new Version, such as Canonical,
+// Trimmed and BumpAlpha. Parsing with a fraction width other than 3 is the
+// exception, since the original doesn't record it. It's mostly useful in
+// tests.
+func (v *Version) MustRoundTrip() Version {
+	reparsed := MustParse(v.original)
+	if !reparsed.Identical(v) {
+		panic(fmt.Sprintf("version %q doesn't round trip: %#v "+
+			"reparses as %#v", v.original, *v, reparsed))
+	}
+	return reparsed
+}
+
 // UnmarshalJSON implements the json.Unmarshaler interface. This allows for
-// extracting the version from a cached version.
+// extracting the version from a cached version. A bare JSON string, such as
+// "v1.2.3", is accepted as well, and is parsed with Parse.
 func (v *Version) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '"' {
+		var str string
+		if err := json.Unmarshal(trimmed, &str); err != nil {
+			return err
+		}
+		pv, err := Parse(str)
+		if err != nil {
+			return err
+		}
+		*v = pv
+		return nil
+	}
+
 	var obj struct {
 		Original string  `json:"original"`
 		Alpha    bool    `json:"alpha"`
@@ -146,6 +613,9 @@ func (v *Version) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	if err := checkComponents(obj.Version); err != nil {
+		return err
+	}
 	v.original = obj.Original
 	v.alpha = obj.Alpha
 	v.qv = obj.Qv
@@ -153,18 +623,181 @@ func (v *Version) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Valid is a quick structural check: it reports whether the version has at
+// least one component and none of them are negative. Parse never gives
+// anything else, but a negative component would throw off Numify and Normal,
+// and the decoders and Builder reject them too. The zero-value Version isn't
+// Valid. See Validate for a thorough check against the original.
+func (v *Version) Valid() bool {
+	return len(v.version) > 0 && checkComponents(v.version) == nil
+}
+
+// Validate checks the internal consistency of a version, by parsing the
+// original string again and making sure the result matches. Versions from
+// Parse always pass, but a version unmarshaled from an untrusted or corrupted
+// cache might not, so it's worth calling after UnmarshalJSON or GobDecode.
+// The zero-value Version doesn't pass, as it has no original.
+func (v *Version) Validate() error {
+	if len(v.version) == 0 {
+		return errors.New("invalid version: no components")
+	}
+	if err := checkComponents(v.version); err != nil {
+		return err
+	}
+	// a "0 but true" original can only have come from WithZeroButTrue
+	expected, err := Parse(v.original, WithZeroButTrue())
+	if err != nil {
+		return fmt.Errorf("invalid version: %w", err)
+	}
+	if v.alpha != expected.alpha {
+		return errors.New("invalid version: alpha flag doesn't " +
+			"match " + v.original)
+	}
+	if v.qv != expected.qv {
+		return errors.New("invalid version: qv flag doesn't match " +
+			v.original)
+	}
+	if !reflect.DeepEqual(v.version, expected.version) {
+		return errors.New("invalid version: components don't match " +
+			v.original)
+	}
+	return nil
+}
+
+// gobVersion is the wire format for GobEncode and GobDecode, since gob only
+// looks at exported fields.
+type gobVersion struct {
+	Original string
+	Alpha    bool
+	Qv       bool
+	Version  []int64
+}
+
+// GobEncode implements the gob.GobEncoder interface. Like MarshalJSON, this is
+// meant for caching, and is both faster and more compact. It has a value
+// receiver so that Versions stored in interfaces can be encoded.
+func (v Version) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(gobVersion{
+		Original: v.original,
+		Alpha:    v.alpha,
+		Qv:       v.qv,
+		Version:  v.version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface, for extracting the
+// version from the output of GobEncode.
+func (v *Version) GobDecode(data []byte) error {
+	var obj gobVersion
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&obj)
+	if err != nil {
+		return err
+	}
+	if err := checkComponents(obj.Version); err != nil {
+		return err
+	}
+	v.original = obj.Original
+	v.alpha = obj.Alpha
+	v.qv = obj.Qv
+	v.version = obj.Version
+	return nil
+}
+
+// binary flags for MarshalBinary
+const (
+	binaryAlpha = 1 << iota
+	binaryQv
+)
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface, with a
+// compact encoding for caches: a flags byte (1 for alpha, 2 for qv), the
+// length of the original as a uvarint followed by its bytes, then the number
+// of components as a uvarint followed by each component as a varint.
+// UnmarshalBinary reproduces the version exactly.
+func (v *Version) MarshalBinary() ([]byte, error) {
+	var flags byte
+	if v.alpha {
+		flags |= binaryAlpha
+	}
+	if v.qv {
+		flags |= binaryQv
+	}
+	data := make([]byte, 0, 1+2*binary.MaxVarintLen64+len(v.original)+
+		len(v.version)*binary.MaxVarintLen64)
+	data = append(data, flags)
+	data = binary.AppendUvarint(data, uint64(len(v.original)))
+	data = append(data, v.original...)
+	data = binary.AppendUvarint(data, uint64(len(v.version)))
+	for _, component := range v.version {
+		data = binary.AppendVarint(data, component)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface, for
+// extracting the version from the output of MarshalBinary.
+func (v *Version) UnmarshalBinary(data []byte) error {
+	errInvalid := errors.New("invalid version: malformed binary encoding")
+	if len(data) == 0 || data[0]&^(binaryAlpha|binaryQv) != 0 {
+		return errInvalid
+	}
+	flags := data[0]
+	data = data[1:]
+	length, n := binary.Uvarint(data)
+	if n <= 0 || length > uint64(len(data)-n) {
+		return errInvalid
+	}
+	original := string(data[n : n+int(length)])
+	data = data[n+int(length):]
+	count, n := binary.Uvarint(data)
+	// every component takes at least a byte
+	if n <= 0 || count > uint64(len(data)-n) {
+		return errInvalid
+	}
+	data = data[n:]
+	var components []int64
+	if count > 0 {
+		components = make([]int64, count)
+	}
+	for i := range components {
+		components[i], n = binary.Varint(data)
+		if n <= 0 {
+			return errInvalid
+		}
+		data = data[n:]
+	}
+	if len(data) != 0 {
+		return errInvalid
+	}
+	if err := checkComponents(components); err != nil {
+		return err
+	}
+	v.original = original
+	v.alpha = flags&binaryAlpha != 0
+	v.qv = flags&binaryQv != 0
+	v.version = components
+	return nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // Comparisons                                                               //
 ///////////////////////////////////////////////////////////////////////////////
 
 // LessThan checks whether a version is older than another.
+// Missing trailing components are treated as zero, the same as Perl's vcmp, so
+// "v1.2" is older than "v1.2.1".
 func (v *Version) LessThan(other *Version) bool {
-	length := min(len(v.version), len(other.version))
+	length := max(len(v.version), len(other.version))
 	for i := 0; i < length; i++ {
-		if v.version[i] < other.version[i] {
+		if v.component(i) < other.component(i) {
 			return true
 		}
-		if v.version[i] > other.version[i] {
+		if v.component(i) > other.component(i) {
 			return false
 		}
 	}
@@ -172,13 +805,14 @@ func (v *Version) LessThan(other *Version) bool {
 }
 
 // GreaterThan checks whether a version is newer than another.
+// Missing trailing components are treated as zero, as with LessThan.
 func (v *Version) GreaterThan(other *Version) bool {
-	length := min(len(v.version), len(other.version))
+	length := max(len(v.version), len(other.version))
 	for i := 0; i < length; i++ {
-		if v.version[i] > other.version[i] {
+		if v.component(i) > other.component(i) {
 			return true
 		}
-		if v.version[i] < other.version[i] {
+		if v.component(i) < other.component(i) {
 			return false
 		}
 	}
@@ -187,11 +821,60 @@ func (v *Version) GreaterThan(other *Version) bool {
 
 // Equal checks whether two versions are the same. This doesn't strictly
 // mean they're identical, it means, for example, "v5.34" counts as the same as
-// "v5.34.0" *or* "v5.34.1".
+// "v5.34.0", though not "v5.34.1".
 func (v *Version) Equal(other *Version) bool {
 	return !(v.LessThan(other) || v.GreaterThan(other))
 }
 
+// StrictEqual checks whether two versions are the same, treating missing
+// trailing components as zero, so "v5.34" is the same as "v5.34.0" but *not*
+// "v5.34.1". Since the comparisons pad with zeroes as well, this is now
+// equivalent to Equal.
+func (v *Version) StrictEqual(other *Version) bool {
+	length := max(len(v.version), len(other.version))
+	for i := 0; i < length; i++ {
+		if v.component(i) != other.component(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// Identical checks whether two versions are the same version object: the same
+// original string, flags and components, without any zero padding. "v1.2" and
+// "v1.2.0" are Equal, but not Identical. This is the notion to use for
+// deduplicating versions as they were written.
+func (v *Version) Identical(other *Version) bool {
+	if v.original != other.original || v.alpha != other.alpha ||
+		v.qv != other.qv || len(v.version) != len(other.version) {
+		return false
+	}
+	for i := range v.version {
+		if v.version[i] != other.version[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// components returns the version's components, treating a version without
+// any, i.e. the zero-value Version, as {0}.
+func (v *Version) components() []int64 {
+	if len(v.version) == 0 {
+		return []int64{0}
+	}
+	return v.version
+}
+
+// component returns the i-th component of the version, or zero if the
+// version doesn't have that many.
+func (v *Version) component(i int) int64 {
+	if i < len(v.version) {
+		return v.version[i]
+	}
+	return 0
+}
+
 // LessThanOrEqual checks whether a version is older or equivalent to
 // another. Same as (LessThan || Equal).
 func (v *Version) LessThanOrEqual(other *Version) bool {
@@ -210,8 +893,126 @@ func (v *Version) NotEqual(other *Version) bool {
 	return !v.Equal(other)
 }
 
+// NumericEqual checks whether two versions numify to the same number, which is
+// how Perl compares a version against a plain number. It agrees with Equal
+// unless a component after the first is 1000 or more: "v1.1000" numifies to
+// 1.1000, the same as "1.1", though their components, {1, 1000} and
+// {1, 100}, differ.
+func (v *Version) NumericEqual(other *Version) bool {
+	return v.NumifyRat().Cmp(other.NumifyRat()) == 0
+}
+
+// InSeries checks whether the version belongs to a release series, i.e.
+// whether the components written in series are a prefix of the version's, so
+// v5.34.2 is in the v5.34 series, but v5.36.0 isn't. Only the components that
+// were written count, so the series v5.34 is not the same as v5.34.0, which
+// v5.34.2 isn't in. Unlike Equal, this is directional: v5.34 isn't in the
+// v5.34.2 series.
+func (v *Version) InSeries(series *Version) bool {
+	for i, component := range series.writtenComponents() {
+		if v.component(i) != component {
+			return false
+		}
+	}
+	return true
+}
+
+// Between checks whether a version falls within the range [low, high). The
+// lower bound is always inclusive, while inclusive controls whether the upper
+// bound is as well, making the range [low, high]. An inverted range, where low
+// is newer than high, contains nothing.
+func (v *Version) Between(low, high *Version, inclusive bool) bool {
+	if low.GreaterThan(high) || v.LessThan(low) {
+		return false
+	}
+	if inclusive {
+		return v.LessThanOrEqual(high)
+	}
+	return v.LessThan(high)
+}
+
+// Distance returns how far apart two versions are, weighted by the first
+// component where they differ, for ranking upgrades by how disruptive they
+// are. The difference in that component, capped at 999999, is multiplied by
+// 10^12 if it's the first (major) component, 10^6 if it's the second (minor),
+// and 1 otherwise (patch, or anything later). Later components are ignored, so
+// any major change is further than any minor change, which in turn is further
+// than any patch. Equal versions, and only those, have a distance of 0; the
+// distance is the same either way round, and alpha flags are ignored.
+func (v *Version) Distance(other *Version) int64 {
+	const maxDiff = 999999
+	weights := []int64{1000000000000, 1000000, 1}
+	length := max(len(v.version), len(other.version))
+	for i := 0; i < length; i++ {
+		a, b := v.component(i), other.component(i)
+		if a == b {
+			continue
+		}
+		diff := uint64(a - b)
+		if a < b {
+			diff = uint64(b - a)
+		}
+		if diff > maxDiff {
+			diff = maxDiff
+		}
+		return int64(diff) * weights[min(i, len(weights)-1)]
+	}
+	return 0
+}
+
+// Clamp returns the version limited to the range [low, high]: low if the
+// version is older than it, high if it's newer than that, and the version
+// itself otherwise. A nil bound leaves that side unbounded. If low is newer
+// than high, low wins.
+func (v *Version) Clamp(low, high *Version) Version {
+	if low != nil && high != nil && low.GreaterThan(high) {
+		return *low
+	}
+	if low != nil && v.LessThan(low) {
+		return *low
+	}
+	if high != nil && v.GreaterThan(high) {
+		return *high
+	}
+	return *v
+}
+
+// Overlay returns the version with the non-zero components of partial laid
+// over it, for filling in defaults: v5.34.0 overlaid with v0.0.1 gives
+// v5.34.1. Components are matched up by position, so a zero in partial leaves
+// the receiver's component alone, and there's no way to overlay a zero. Where
+// partial is longer, its extra components are added, zeros included, and
+// where it's shorter, the receiver's remaining components are kept. The result
+// is a stable version in the receiver's form, written as Builder would,
+// unless it's decimal and a component won't fit in three digits, in which case
+// it's qv. If either version has a negative component, the result is the zero
+// Version.
+func (v *Version) Overlay(partial *Version) Version {
+	components := append([]int64{}, v.components()...)
+	for i, component := range partial.components() {
+		if i >= len(components) {
+			components = append(components, component)
+		} else if component != 0 {
+			components[i] = component
+		}
+	}
+	builder := NewBuilder()
+	for _, component := range components {
+		builder.Component(component)
+	}
+	if !v.qv {
+		if pv, err := builder.Qv(false).Build(); err == nil {
+			return pv
+		}
+	}
+	pv, _ := builder.Qv(true).Build()
+	return pv
+}
+
 // Compare compares two versions. It returns -1 if the receiver is older,
-// 0 if they're equivalent, and 1 if the receiver is newer.
+// 0 if they're equivalent, and 1 if the receiver is newer. Like Perl's vcmp,
+// only the components count, so "v1.2.3_0" and "v1.2.30" are equivalent; see
+// CompareStable for an ordering that tells them apart.
 func (v *Version) Compare(other *Version) int {
 	if v.LessThan(other) {
 		return -1
@@ -222,7 +1023,143 @@ func (v *Version) Compare(other *Version) int {
 	return 0
 }
 
+// CompareStable is Compare with a tie-break for sorting: when the components
+// are the same, an alpha version sorts before a non-alpha one, so "v1.2.3_0"
+// comes before "v1.2.30". Perl treats those as equal, so this isn't what <=>
+// does, but it keeps the order of a sorted list from depending on its input.
+func (v *Version) CompareStable(other *Version) int {
+	if cmp := v.Compare(other); cmp != 0 {
+		return cmp
+	}
+	if v.alpha && !other.alpha {
+		return -1
+	}
+	if !v.alpha && other.alpha {
+		return 1
+	}
+	return 0
+}
+
+// Cmp is Compare for values rather than pointers, so it can be called directly
+// on function results, as in MustParse("1.2").Cmp(MustParse("v1.300.0")).
+func (v Version) Cmp(other Version) int {
+	return v.Compare(&other)
+}
+
+// Less reports whether the version sorts before other, by the same ordering as
+// Cmp. It's handy as a sort.Slice or slices.SortFunc building block.
+func (v Version) Less(other Version) bool {
+	return v.Cmp(other) < 0
+}
+
+// Ordering is the result of CompareOrdering.
+type Ordering int
+
+const (
+	// Less means the receiver is older.
+	Less Ordering = -1
+	// Equal means the versions are equivalent.
+	Equal Ordering = 0
+	// Greater means the receiver is newer.
+	Greater Ordering = 1
+)
+
+// String returns the ordering's name, e.g. "Less".
+func (o Ordering) String() string {
+	switch o {
+	case Less:
+		return "Less"
+	case Equal:
+		return "Equal"
+	case Greater:
+		return "Greater"
+	default:
+		return fmt.Sprintf("Ordering(%d)", int(o))
+	}
+}
+
+// CompareOrdering is Compare with a typed result, for callers that would
+// rather not compare against -1, 0 and 1. The values match Compare's.
+func (v *Version) CompareOrdering(other *Version) Ordering {
+	return Ordering(v.Compare(other))
+}
+
+// Spaceship is Perl's <=> operator on versions, returning -1, 0 or 1, for
+// translating code like sort { $a <=> $b }. It's Compare as a function, so
+// like Perl, it finds "1.02_03" and "1.020300" equal. A nil version counts as
+// undef, as an undefined scalar would in Perl.
+func Spaceship(a, b *Version) int {
+	if a == nil {
+		a = &Version{}
+	}
+	if b == nil {
+		b = &Version{}
+	}
+	return a.Compare(b)
+}
+
+// CompareString parses other and compares the version against it, the same as
+// Compare. It returns an error if other doesn't parse.
+func (v *Version) CompareString(other string) (int, error) {
+	otherPv, err := Parse(other)
+	if err != nil {
+		return 0, err
+	}
+	return v.Compare(&otherPv), nil
+}
+
+// Max returns the newest of the given versions, and false if there aren't
+// any. When several are equivalent, the first of them is returned.
+func Max(vs ...Version) (Version, bool) {
+	if len(vs) == 0 {
+		return Version{}, false
+	}
+	newest := vs[0]
+	for i := range vs[1:] {
+		if vs[i+1].GreaterThan(&newest) {
+			newest = vs[i+1]
+		}
+	}
+	return newest, true
+}
+
+// Min returns the oldest of the given versions, and false if there aren't
+// any. When several are equivalent, the first of them is returned.
+func Min(vs ...Version) (Version, bool) {
+	if len(vs) == 0 {
+		return Version{}, false
+	}
+	oldest := vs[0]
+	for i := range vs[1:] {
+		if vs[i+1].LessThan(&oldest) {
+			oldest = vs[i+1]
+		}
+	}
+	return oldest, true
+}
+
+// Dedup returns the versions with any that are Equal to an earlier one
+// removed, keeping the order of the rest. Different spellings of the same
+// version, such as "1.002", "v1.2" and "v1.2.0", count as duplicates, and the
+// first of them is kept. Versions are matched up by their Bytes, so this takes
+// linear time, even for whole mirror candidate lists.
+func Dedup(vs []Version) []Version {
+	deduped := make([]Version, 0, len(vs))
+	seen := make(map[string]struct{}, len(vs))
+	for i := range vs {
+		key := string(vs[i].Bytes())
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		deduped = append(deduped, vs[i])
+	}
+	return deduped
+}
+
 func init() {
 	strictRegexp.Longest()
+	strictDottedRegexp.Longest()
 	laxRegexp.Longest()
+	laxSearchRegexp.Longest()
 }
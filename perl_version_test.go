@@ -14,8 +14,22 @@
 package perl_version
 
 import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
 	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -511,9 +525,19 @@ func TestGetFractionValue(t *testing.T) {
 		{"0020034", []int64{2, 3, 400}},
 		{"00200304", []int64{2, 3, 40}},
 		{"002003004", []int64{2, 3, 4}},
+		{".5", []int64{500}},
+		{"999999999999999999999", []int64{999, 999, 999, 999, 999, 999,
+			999}},
+		{"0000000000000000000001", []int64{0, 0, 0, 0, 0, 0, 0, 100}},
+		{"1234567890123456789012345", []int64{123, 456, 789, 12, 345,
+			678, 901, 234, 500}},
 	}
 	for _, test := range tests {
-		values := getFractionValue(test.input)
+		values, err := getFractionValue(test.input, 3)
+		if err != nil {
+			t.Fatalf("getFractionValue(%q) returned error: %v",
+				test.input, err)
+		}
 		if len(values) != len(test.output) {
 			t.Errorf("getFractionValue(%q) => %d, expected %d",
 				test.input, len(values), len(test.output))
@@ -527,6 +551,13 @@ func TestGetFractionValue(t *testing.T) {
 	}
 }
 
+func BenchmarkGetFractionValue(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = getFractionValue("1234567890123456789012345", 3)
+	}
+}
+
 func TestNewPerlVersion(t *testing.T) {
 	tests := []struct {
 		version  string
@@ -808,52 +839,60 @@ func TestPerlVersion_IsAlpha(t *testing.T) {
 	}
 }
 
+// isQvTests is checked against Perl's is_qv; any dotted form counts, however
+// many components it has.
+var isQvTests = []struct {
+	version  string
+	expected bool
+}{
+	{".1", false},
+	{".1.2", true},
+	{".1.2.3", true},
+	{"0.1.2", true},
+	{"1.2.3.4", true},
+	{"1.2.3_4", true},
+	{"1.2.3.4_5", true},
+	{"0", false},
+	{"0.0", false},
+	{"0.123", false},
+	{"01", false},
+	{"01.0203", false},
+	{"1.", false},
+	{"1.00", false},
+	{"1.00001", false},
+	{"1.002", false},
+	{"1.002003", false},
+	{"1.00203", false},
+	{"1.0023", false},
+	{"1.02", false},
+	{"1.0203", false},
+	{"1.02_03", false},
+	{"1.2", false},
+	{"1.2.3", true},
+	{"1.2345_01", false},
+	{"12.345", false},
+	{"42", false},
+	{"undef", false},
+	{"v0", true},
+	{"v0.0.0", true},
+	{"v0.1.2", true},
+	{"v01", true},
+	{"v01.02.03", true},
+	{"v1", true},
+	{"v1.02_03", true},
+	{"v1.2", true},
+	{"v1.2.3", true},
+	{"v1.2.3.4", true},
+	{"v1.2.30", true},
+	{"v1.2.3_0", true},
+	{"v1.2345.6", true},
+	{"v1.2_3", true},
+	{"1.11111111111", false},
+	{"2147483647.000", false},
+}
+
 func TestPerlVersion_IsQv(t *testing.T) {
-	tests := []struct {
-		version  string
-		expected bool
-	}{
-		{".1", false},
-		{".1.2", true},
-		{"0", false},
-		{"0.0", false},
-		{"0.123", false},
-		{"01", false},
-		{"01.0203", false},
-		{"1.", false},
-		{"1.00", false},
-		{"1.00001", false},
-		{"1.002", false},
-		{"1.002003", false},
-		{"1.00203", false},
-		{"1.0023", false},
-		{"1.02", false},
-		{"1.0203", false},
-		{"1.02_03", false},
-		{"1.2", false},
-		{"1.2.3", true},
-		{"1.2345_01", false},
-		{"12.345", false},
-		{"42", false},
-		{"undef", false},
-		{"v0", true},
-		{"v0.0.0", true},
-		{"v0.1.2", true},
-		{"v01", true},
-		{"v01.02.03", true},
-		{"v1", true},
-		{"v1.02_03", true},
-		{"v1.2", true},
-		{"v1.2.3", true},
-		{"v1.2.3.4", true},
-		{"v1.2.30", true},
-		{"v1.2.3_0", true},
-		{"v1.2345.6", true},
-		{"v1.2_3", true},
-		{"1.11111111111", false},
-		{"2147483647.000", false},
-	}
-	for _, test := range tests {
+	for _, test := range isQvTests {
 		pv, err := Parse(test.version)
 		if err != nil {
 			t.Fatalf("NewPerlVersion(%q) returned error: %v",
@@ -867,6 +906,29 @@ func TestPerlVersion_IsQv(t *testing.T) {
 	}
 }
 
+func TestIsQvJSONRoundTrip(t *testing.T) {
+	for _, test := range isQvTests {
+		pv := MustParse(test.version)
+		for _, marshal := range []func() ([]byte, error){pv.MarshalJSON,
+			pv.MarshalJSONVerbose} {
+			data, err := marshal()
+			if err != nil {
+				t.Fatalf("marshaling %q returned error: %v",
+					test.version, err)
+			}
+			var decoded Version
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("json.Unmarshal(%s) returned error: %v", data,
+					err)
+			}
+			if decoded.IsQv() != test.expected {
+				t.Errorf("json.Unmarshal(%s).IsQv() => %t, expected %t",
+					data, decoded.IsQv(), test.expected)
+			}
+		}
+	}
+}
+
 func TestPerlVersion_Normal(t *testing.T) {
 	tests := []struct {
 		version  string
@@ -1187,3 +1249,2877 @@ func TestVersion_MarshalJSON(t *testing.T) {
 			actual, input)
 	}
 }
+
+func TestParseStrict(t *testing.T) {
+	valid := []string{"0", "0.0", "0.123", "1.00", "1.02", "12.345", "42",
+		"v0.0.0", "v0.1.2", "v1.2.3", "v1.2.3.4"}
+	for _, version := range valid {
+		pv, err := ParseStrict(version)
+		if err != nil {
+			t.Errorf("ParseStrict(%q) returned error: %v", version,
+				err)
+			continue
+		}
+		expected := MustParse(version)
+		if !reflect.DeepEqual(pv, expected) {
+			t.Errorf("ParseStrict(%q) => %+v, expected %+v",
+				version, pv, expected)
+		}
+	}
+
+	// all of these are valid lax versions
+	invalid := []string{".1", ".1.2", "01", "1.", "1.2.3", "1.02_03",
+		"1.2_3", "undef", "v01", "v1", "v1.2", "v1.2.3_0", "v1.2345.6",
+		"v1.2_3"}
+	for _, version := range invalid {
+		if _, err := Parse(version); err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", version, err)
+		}
+		if _, err := ParseStrict(version); err == nil {
+			t.Errorf("ParseStrict(%q) => nil error, expected error",
+				version)
+		}
+	}
+
+	if _, err := ParseStrict("foo"); err == nil {
+		t.Errorf("ParseStrict(%q) => nil error, expected error", "foo")
+	}
+}
+
+func TestHasLeadingZero(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{"01", true},
+		{"0", false},
+		{"10", false},
+		{"0.01", false},
+		{"01.02", true},
+		{"v01.2.3", true},
+		{"v0.1.2", false},
+		{".01", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if actual := HasLeadingZero(test.version); actual != test.expected {
+			t.Errorf("HasLeadingZero(%q) => %t, expected %t",
+				test.version, actual, test.expected)
+		}
+	}
+
+	for _, version := range []string{"0", "10"} {
+		if _, err := ParseStrict(version); err != nil {
+			t.Errorf("ParseStrict(%q) returned error: %v", version, err)
+		}
+	}
+	_, err := ParseStrict("01")
+	if err == nil || !strings.Contains(err.Error(), "leading zero") {
+		t.Errorf("ParseStrict(%q) => %v, expected a leading zero error",
+			"01", err)
+	}
+}
+
+func TestParseLax(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected []int64
+	}{
+		{".1", []int64{0, 100}},
+		{".1.2", []int64{0, 1, 2}},
+		{"01", []int64{1}},
+		{"1.", []int64{1, 0}},
+		{"1.02_03", []int64{1, 20, 300}},
+		{"undef", []int64{0}},
+		{"v1", []int64{1, 0, 0}},
+		{"v1.2.3", []int64{1, 2, 3}},
+		{"v1.2.3.4", []int64{1, 2, 3, 4}},
+		{"v1.2.3.4_5", []int64{1, 2, 3, 45}},
+	}
+	for _, test := range tests {
+		pv, err := ParseLax(test.version)
+		if err != nil {
+			t.Errorf("ParseLax(%q) returned error: %v",
+				test.version, err)
+			continue
+		}
+		if !reflect.DeepEqual(pv.version, test.expected) {
+			t.Errorf("ParseLax(%q).version => %v, expected %v",
+				test.version, pv.version, test.expected)
+		}
+	}
+
+	if _, err := Parse("1_0"); err != errAlphaWithoutDecimal {
+		t.Errorf("Parse(%q) => %v, expected %v", "1_0", err,
+			errAlphaWithoutDecimal)
+	}
+	if _, err := ParseLax("1_0"); err != errAlphaWithoutDecimal {
+		t.Errorf("ParseLax(%q) => %v, expected %v", "1_0", err,
+			errAlphaWithoutDecimal)
+	}
+
+	if _, err := ParseLax("foo"); err == nil {
+		t.Errorf("ParseLax(%q) => nil error, expected error", "foo")
+	}
+}
+
+func TestParseQvWithoutComponent(t *testing.T) {
+	for _, version := range []string{"v", "v.", "v.."} {
+		if _, err := Parse(version); err != errQvWithoutComponent {
+			t.Errorf("Parse(%q) => %v, expected %v", version, err,
+				errQvWithoutComponent)
+		}
+		if _, err := ParseStrict(version); err != errQvWithoutComponent {
+			t.Errorf("ParseStrict(%q) => %v, expected %v", version,
+				err, errQvWithoutComponent)
+		}
+	}
+
+	// like Perl, the lax grammar wants an integer straight after the v, so
+	// "v.1" is invalid too, just not truncated
+	for _, version := range []string{"v.1", "v_1", ".v"} {
+		_, err := Parse(version)
+		if err == nil || err == errQvWithoutComponent {
+			t.Errorf("Parse(%q) => %v, expected a generic error",
+				version, err)
+		}
+	}
+	if _, err := Parse("v1"); err != nil {
+		t.Errorf("Parse(%q) returned error: %v", "v1", err)
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	inputs := append([]string{"v1.2.3", "1.02_03", "undef", "0 but true",
+		"foo"}, parseBoundaryInputs...)
+	for _, version := range inputs {
+		b := []byte(version)
+		actual, err := ParseBytes(b)
+		expected, expectedErr := Parse(version)
+		if (err == nil) != (expectedErr == nil) ||
+			(err != nil && err.Error() != expectedErr.Error()) {
+			t.Errorf("ParseBytes(%q) => %v, expected %v", version, err,
+				expectedErr)
+			continue
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("ParseBytes(%q) => %#v, expected %#v", version,
+				actual, expected)
+		}
+		// the buffer isn't retained
+		for i := range b {
+			b[i] = 'x'
+		}
+		if actual.Raw() != expected.Raw() {
+			t.Errorf("ParseBytes(%q).Raw() => %q after changing the "+
+				"buffer", version, actual.Raw())
+		}
+	}
+
+	pv, err := ParseBytes([]byte(" V1.2 "), WithTrimSpace(),
+		WithUppercaseV())
+	if err != nil || pv.Raw() != "v1.2" {
+		t.Errorf("ParseBytes(%q) with options => %q, %v, expected %q",
+			" V1.2 ", pv.Raw(), err, "v1.2")
+	}
+
+	// rejections from the byte slice have to match Parse's too
+	optionSets := [][]Option{{WithLaxOnly()}, {WithStrictOnly()},
+		{WithTrimSpace()}, {WithEmptyAsUndef()}, {WithZeroButTrue()},
+		{WithScientificNotation()}, {WithFractionWidth(0)},
+		{WithUppercaseV(), WithLaxOnly()}}
+	for _, opts := range optionSets {
+		for _, version := range []string{"foo", " 1.2 ", "", "v", "0E0",
+			"1e3", "V1.2", "1.2.3", "1_0", "x1.2"} {
+			actual, err := ParseBytes([]byte(version), opts...)
+			expected, expectedErr := Parse(version, opts...)
+			if (err == nil) != (expectedErr == nil) ||
+				(err != nil && err.Error() != expectedErr.Error()) ||
+				!reflect.DeepEqual(actual, expected) {
+				t.Errorf("ParseBytes(%q) with options => %#v, %v, "+
+					"expected %#v, %v", version, actual, err,
+					expected, expectedErr)
+			}
+		}
+	}
+
+	// invalid input isn't copied before it's rejected
+	invalid := []byte("not a version")
+	bytesAllocs := testing.AllocsPerRun(100, func() {
+		_, _ = ParseBytes(invalid)
+	})
+	stringAllocs := testing.AllocsPerRun(100, func() {
+		_, _ = Parse(string(invalid))
+	})
+	if bytesAllocs >= stringAllocs {
+		t.Errorf("ParseBytes(%q) made %v allocations, expected fewer "+
+			"than Parse's %v", invalid, bytesAllocs, stringAllocs)
+	}
+}
+
+func TestSubmatchBuffers(t *testing.T) {
+	if n := laxRegexp.NumSubexp() + 1; n != laxSubmatches {
+		t.Errorf("laxRegexp has %d submatches, expected %d", n,
+			laxSubmatches)
+	}
+	if n := strictRegexp.NumSubexp() + 1; n != strictSubmatches {
+		t.Errorf("strictRegexp has %d submatches, expected %d", n,
+			strictSubmatches)
+	}
+}
+
+func TestParseWithKind(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected Kind
+	}{
+		{"undef", UndefKind},
+		{"1", StrictDecimal},
+		{"1.002", StrictDecimal},
+		{"0.1", StrictDecimal},
+		{"v1.2.3", StrictDotted},
+		{"v1.2.3.4", StrictDotted},
+		{"1.02_03", LaxDecimal},
+		{".1", LaxDecimal},
+		{"1.", LaxDecimal},
+		{"01", LaxDecimal},
+		{"v1.2", LaxDotted},
+		{"v1", LaxDotted},
+		{"1.2.3", LaxDotted},
+		{".1.2", LaxDotted},
+		{"v1.2.3_4", LaxDotted},
+	}
+	for _, test := range tests {
+		pv, kind, err := ParseWithKind(test.version)
+		if err != nil {
+			t.Errorf("ParseWithKind(%q) returned error: %v",
+				test.version, err)
+			continue
+		}
+		if kind != test.expected {
+			t.Errorf("ParseWithKind(%q) => %v, expected %v",
+				test.version, kind, test.expected)
+		}
+		expected := MustParse(test.version)
+		if !pv.Identical(&expected) {
+			t.Errorf("ParseWithKind(%q) => %#v, expected %#v",
+				test.version, pv, expected)
+		}
+	}
+
+	_, kind, _ := ParseWithKind("", WithEmptyAsUndef())
+	if kind != UndefKind {
+		t.Errorf("ParseWithKind(%q) => %v, expected %v", "", kind,
+			UndefKind)
+	}
+	_, kind, _ = ParseWithKind("1.002", WithLaxOnly())
+	if kind != LaxDecimal {
+		t.Errorf("ParseWithKind(%q, WithLaxOnly()) => %v, expected %v",
+			"1.002", kind, LaxDecimal)
+	}
+	_, kind, _ = ParseWithKind("0E0", WithZeroButTrue())
+	if kind != ZeroButTrueKind {
+		t.Errorf("ParseWithKind(%q, WithZeroButTrue()) => %v, expected %v",
+			"0E0", kind, ZeroButTrueKind)
+	}
+	if _, _, err := ParseWithKind("foo"); err == nil {
+		t.Errorf("ParseWithKind(%q) => nil error, expected error", "foo")
+	}
+	if s := Kind(42).String(); s != "Kind(42)" {
+		t.Errorf("Kind(42).String() => %q, expected %q", s, "Kind(42)")
+	}
+}
+
+func TestLaxForm(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"undef", "undef"},
+		{"v1", "dotted A"},
+		{"v1.2", "dotted A"},
+		{"v1.2.3_4", "dotted A"},
+		{"1.2.3", "dotted B"},
+		{".1.2", "dotted B"},
+		{"1.2.3_4", "dotted B"},
+		{"1", "decimal A"},
+		{"1.", "decimal A"},
+		{"1.2_3", "decimal A"},
+		{"01.002", "decimal A"},
+		{".1", "decimal B"},
+		{".1_2", "decimal B"},
+	}
+	for _, test := range tests {
+		actual, err := LaxForm(test.version)
+		if err != nil {
+			t.Errorf("LaxForm(%q) returned error: %v", test.version, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("LaxForm(%q) => %q, expected %q", test.version,
+				actual, test.expected)
+		}
+	}
+
+	for _, version := range []string{"", "foo", "1_0", "v1.2 ", "1..2"} {
+		if _, err := LaxForm(version); err == nil {
+			t.Errorf("LaxForm(%q) => nil error, expected error", version)
+		}
+	}
+}
+
+func TestParseLaxMatchesParse(t *testing.T) {
+	inputs := append([]string{"1.2", "v1.2.3", "1.02_03", "undef", "x1.2",
+		"1.2.", "foo"}, parseBoundaryInputs...)
+	for _, version := range inputs {
+		lax, laxErr := ParseLax(version)
+		pv, err := Parse(version)
+		if (laxErr == nil) != (err == nil) {
+			t.Errorf("ParseLax(%q) => %v, but Parse => %v", version,
+				laxErr, err)
+			continue
+		}
+		if !reflect.DeepEqual(lax, pv) {
+			t.Errorf("ParseLax(%q) => %#v, but Parse => %#v", version,
+				lax, pv)
+		}
+	}
+}
+
+func TestVersion_StrictEqual(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected bool
+	}{
+		{"v1.2", "v1.2", true},
+		{"v1.2", "v1.2.0", true},
+		{"v1.2.0", "v1.2", true},
+		{"v1.2", "v1.2.0.0", true},
+		{"v1.2", "v1.2.5", false},
+		{"v1.2.5", "v1.2", false},
+		{"v1.2.0.1", "v1.2", false},
+		{"1.2", "1.200", true},
+		{"1.2", "1.2001", false},
+		{"undef", "0", true},
+		{"undef", "v0.0.1", false},
+	}
+	for _, test := range tests {
+		a := MustParse(test.a)
+		b := MustParse(test.b)
+		if a.StrictEqual(&b) != test.expected {
+			t.Errorf("%q.StrictEqual(%q) => %t, expected %t",
+				test.a, test.b, !test.expected, test.expected)
+		}
+	}
+}
+
+func TestVersion_ComparePadding(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"v1.2", "v1.2.0", 0},
+		{"v1.2", "v1.2.0.0", 0},
+		{"v1.2", "v1.2.1", -1},
+		{"v1.2.1", "v1.2", 1},
+		{"v1.2", "v1.2.0.5", -1},
+		{"v1.2.0.5", "v1.2", 1},
+		{"1.2", "1.200001", -1},
+		{"undef", "v0.0.1", -1},
+		{"0", "v0.0.0", 0},
+	}
+	for _, test := range tests {
+		a := MustParse(test.a)
+		b := MustParse(test.b)
+		if actual := a.Compare(&b); actual != test.expected {
+			t.Errorf("%q.Compare(%q) => %d, expected %d", test.a,
+				test.b, actual, test.expected)
+		}
+		if a.LessThan(&b) != (test.expected < 0) {
+			t.Errorf("%q.LessThan(%q) => %t, expected %t", test.a,
+				test.b, a.LessThan(&b), test.expected < 0)
+		}
+		if a.GreaterThan(&b) != (test.expected > 0) {
+			t.Errorf("%q.GreaterThan(%q) => %t, expected %t",
+				test.a, test.b, a.GreaterThan(&b),
+				test.expected > 0)
+		}
+		if a.Equal(&b) != (test.expected == 0) {
+			t.Errorf("%q.Equal(%q) => %t, expected %t", test.a,
+				test.b, a.Equal(&b), test.expected == 0)
+		}
+	}
+}
+
+func TestVersion_Clone(t *testing.T) {
+	for _, version := range []string{"undef", "1.02_03", "v1.2.3.4"} {
+		pv := MustParse(version)
+		clone := pv.Clone()
+		if !reflect.DeepEqual(clone, pv) {
+			t.Errorf("NewPerlVersion(%q).Clone() => %+v, expected %+v",
+				version, clone, pv)
+		}
+		clone.version[0] = -1
+		if pv.version[0] == -1 {
+			t.Errorf("NewPerlVersion(%q).Clone() shares the "+
+				"internal version slice", version)
+		}
+	}
+
+	var zero Version
+	if clone := zero.Clone(); !reflect.DeepEqual(clone, zero) {
+		t.Errorf("Version{}.Clone() => %+v, expected %+v", clone, zero)
+	}
+}
+
+func TestIsLaxIsStrict(t *testing.T) {
+	tests := []struct {
+		version string
+		lax     bool
+		strict  bool
+	}{
+		{"v1.2.3", true, true},
+		{"1.02", true, true},
+		{"0", true, true},
+		{"undef", true, false},
+		{"v1.2", true, false},
+		{"01", true, false},
+		{"1.2_3", true, false},
+		{"1_0", true, false},
+		{"garbage", false, false},
+		{"x1.2", false, false},
+		{"", false, false},
+	}
+	for _, test := range tests {
+		if actual := IsLax(test.version); actual != test.lax {
+			t.Errorf("IsLax(%q) => %t, expected %t", test.version,
+				actual, test.lax)
+		}
+		if actual := IsStrict(test.version); actual != test.strict {
+			t.Errorf("IsStrict(%q) => %t, expected %t",
+				test.version, actual, test.strict)
+		}
+	}
+}
+
+func TestSortStrings(t *testing.T) {
+	shuffled := []string{"v2.0.0", "1.02_03", "0.9", "v1.2.3", "undef",
+		"1.020300", "1.2", "v1.2", "1.10", "v1.2.3_4", "1.002"}
+	expected := []string{"undef", "0.9", "v1.2", "1.002", "v1.2.3",
+		"v1.2.3_4", "1.02_03", "1.020300", "1.10", "1.2", "v2.0.0"}
+	if err := SortStrings(shuffled); err != nil {
+		t.Fatalf("SortStrings() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(shuffled, expected) {
+		t.Errorf("SortStrings() => %q, expected %q", shuffled, expected)
+	}
+	for i := range shuffled[1:] {
+		a, b := MustParse(shuffled[i]), MustParse(shuffled[i+1])
+		if a.Compare(&b) > 0 {
+			t.Errorf("SortStrings() put %q before %q", shuffled[i],
+				shuffled[i+1])
+		}
+	}
+
+	invalid := []string{"v1.2.3", "foo", "1.0"}
+	if err := SortStrings(invalid); err == nil ||
+		!strings.Contains(err.Error(), `"foo"`) {
+		t.Errorf("SortStrings(%q) => %v, expected an error about %q",
+			invalid, err, "foo")
+	}
+	if !reflect.DeepEqual(invalid, []string{"v1.2.3", "foo", "1.0"}) {
+		t.Errorf("SortStrings() changed the slice despite an error: %q",
+			invalid)
+	}
+	if err := SortStrings(nil); err != nil {
+		t.Errorf("SortStrings(nil) returned error: %v", err)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	input := []string{"v1.2.3", "foo", "1.02", "", "undef"}
+	parsed, err := ParseAll(input)
+	if err == nil {
+		t.Fatalf("ParseAll(%q) => nil error, expected error", input)
+	}
+	if len(parsed) != len(input) {
+		t.Fatalf("len(ParseAll(%q)) => %d, expected %d", input,
+			len(parsed), len(input))
+	}
+	for i, version := range input {
+		expected, parseErr := Parse(version)
+		if parseErr != nil {
+			expected = Version{}
+			if !strings.Contains(err.Error(), parseErr.Error()) {
+				t.Errorf("ParseAll(%q) error %q doesn't mention "+
+					"%q", input, err, parseErr)
+			}
+		}
+		if !reflect.DeepEqual(parsed[i], expected) {
+			t.Errorf("ParseAll(%q)[%d] => %+v, expected %+v", input,
+				i, parsed[i], expected)
+		}
+	}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != 2 {
+		t.Errorf("ParseAll(%q) joined %d errors, expected 2", input,
+			len(lines))
+	}
+
+	if _, err := ParseAll([]string{"v1.2.3", "1.2"}); err != nil {
+		t.Errorf("ParseAll() returned error: %v", err)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		version string
+		valid   bool
+		reason  string
+	}{
+		{"v1.2.3", true, ""},
+		{"1.02_03", true, ""},
+		{"undef", true, ""},
+		{"", false, ReasonEmpty},
+		{"1_0", false, ReasonAlphaWithoutDecimal},
+		{"foo", false, ReasonNoMatchingGrammar},
+		{"x1.2", false, ReasonNoMatchingGrammar},
+		{"1.2.", false, ReasonNoMatchingGrammar},
+	}
+	for _, test := range tests {
+		valid, reason := Explain(test.version)
+		if valid != test.valid || reason != test.reason {
+			t.Errorf("Explain(%q) => (%t, %q), expected (%t, %q)",
+				test.version, valid, reason, test.valid,
+				test.reason)
+		}
+		if valid != IsValid(test.version) {
+			t.Errorf("Explain(%q) disagrees with IsValid",
+				test.version)
+		}
+	}
+}
+
+func TestVersion_Between(t *testing.T) {
+	tests := []struct {
+		version   string
+		low       string
+		high      string
+		inclusive bool
+		expected  bool
+	}{
+		{"v1.2.3", "v1.0.0", "v2.0.0", false, true},
+		{"v1.0.0", "v1.0.0", "v2.0.0", false, true},
+		{"v1.0", "v1.0.0", "v2.0.0", false, true},
+		{"v0.9.9", "v1.0.0", "v2.0.0", false, false},
+		{"v2.0.0", "v1.0.0", "v2.0.0", false, false},
+		{"v2.0.0", "v1.0.0", "v2.0.0", true, true},
+		{"v2", "v1.0.0", "v2.0.0", true, true},
+		{"v2.0.1", "v1.0.0", "v2.0.0", true, false},
+		{"1.5", "1.0", "2.0", false, true},
+		{"undef", "0", "1", false, true},
+		{"v1.5.0", "v1.5.0", "v1.5.0", true, true},
+		{"v1.5.0", "v1.5.0", "v1.5.0", false, false},
+		// inverted ranges
+		{"v1.5.0", "v2.0.0", "v1.0.0", false, false},
+		{"v1.5.0", "v2.0.0", "v1.0.0", true, false},
+	}
+	for _, test := range tests {
+		v := MustParse(test.version)
+		low := MustParse(test.low)
+		high := MustParse(test.high)
+		if actual := v.Between(&low, &high, test.inclusive); actual !=
+			test.expected {
+			t.Errorf("%q.Between(%q, %q, %t) => %t, expected %t",
+				test.version, test.low, test.high,
+				test.inclusive, actual, test.expected)
+		}
+	}
+}
+
+func TestMaxMin(t *testing.T) {
+	tests := []struct {
+		versions []string
+		max      string
+		min      string
+	}{
+		{[]string{"v1.2.3"}, "v1.2.3", "v1.2.3"},
+		{[]string{"v1.2.3", "v2.0.0", "v0.1.0"}, "v2.0.0", "v0.1.0"},
+		{[]string{"1.02", "undef", "v1.2.3", "0.5"}, "1.02", "undef"},
+		{[]string{"v1.2", "v1.2.0", "v1.2.0.0"}, "v1.2", "v1.2"},
+		{[]string{"undef", "undef", "undef"}, "undef", "undef"},
+		{[]string{"0", "undef"}, "0", "0"},
+	}
+	for _, test := range tests {
+		versions := make([]Version, len(test.versions))
+		for i, version := range test.versions {
+			versions[i] = MustParse(version)
+		}
+		newest, ok := Max(versions...)
+		if !ok || newest.Raw() != test.max {
+			t.Errorf("Max(%q) => (%q, %t), expected (%q, true)",
+				test.versions, newest.Raw(), ok, test.max)
+		}
+		oldest, ok := Min(versions...)
+		if !ok || oldest.Raw() != test.min {
+			t.Errorf("Min(%q) => (%q, %t), expected (%q, true)",
+				test.versions, oldest.Raw(), ok, test.min)
+		}
+	}
+
+	if _, ok := Max(); ok {
+		t.Errorf("Max() => true, expected false")
+	}
+	if _, ok := Min(); ok {
+		t.Errorf("Min() => true, expected false")
+	}
+}
+
+func TestVersion_NumifyString(t *testing.T) {
+	// these are the results of Perl's numify
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"0", "0.000"},
+		{"42", "42.000"},
+		{"undef", "0.000"},
+		{"v1", "1.000000"},
+		{"1.", "1.000"},
+		{"1.2", "1.200"},
+		{"1.02_03", "1.020300"},
+		{"v1.2_3", "1.023000"},
+		{"v1.2.3", "1.002003"},
+		{"v1.2345.6", "1.2345006"},
+		{"v1.2.3.4.5.6", "1.002003004005006"},
+		{"1.11111111111", "1.111111111110"},
+		{"2147483647.000", "2147483647.000"},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		if actual := pv.NumifyString(); actual != test.expected {
+			t.Errorf("NewPerlVersion(%q).NumifyString() => %q, "+
+				"expected %q", test.version, actual,
+				test.expected)
+		}
+	}
+}
+
+func TestVersion_NumifyRat(t *testing.T) {
+	versions := []string{"undef", "0", "0.000001", "v0.0.2", "0.1",
+		"v1.2.3.4.5.5", "v1.2.3.4.5.6", "1.002003004005007", "v1.2.4",
+		"1.2", "1.200000000001", "v1.200.0.0.1", "1.999999999999999",
+		"2", "v2.0.0.0.0.0.1", "42"}
+	for i, a := range versions {
+		aPv := MustParse(a)
+		for _, b := range versions[i:] {
+			bPv := MustParse(b)
+			expected := aPv.Compare(&bPv)
+			actual := aPv.NumifyRat().Cmp(bPv.NumifyRat())
+			if actual != expected {
+				t.Errorf("%q.NumifyRat().Cmp(%q) => %d, "+
+					"expected %d", a, b, actual, expected)
+			}
+		}
+	}
+
+	pv := MustParse("v1.2.3.4.5.6")
+	expected := big.NewRat(1002003004005006, 1000000000000000)
+	if pv.NumifyRat().Cmp(expected) != 0 {
+		t.Errorf("NewPerlVersion(%q).NumifyRat() => %v, expected %v",
+			"v1.2.3.4.5.6", pv.NumifyRat(), expected)
+	}
+}
+
+func TestVersion_GobEncode(t *testing.T) {
+	gob.Register(Version{})
+	type entry struct {
+		Module  string
+		Version Version
+		Any     interface{}
+	}
+	for _, version := range []string{"undef", "1.02_03", "v1.2.3.4",
+		".1.2"} {
+		input := entry{
+			Module:  "Foo::Bar",
+			Version: MustParse(version),
+			Any:     MustParse(version),
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(&input); err != nil {
+			t.Fatalf("Version.GobEncode() returned error: %v", err)
+		}
+		var actual entry
+		if err := gob.NewDecoder(&buf).Decode(&actual); err != nil {
+			t.Fatalf("Version.GobDecode() returned error: %v", err)
+		}
+		if !reflect.DeepEqual(actual, input) {
+			t.Errorf("Version.GobDecode() => %+v, expected %+v",
+				actual, input)
+		}
+	}
+}
+
+func TestVersion_Valid(t *testing.T) {
+	for _, version := range []string{"v1.2.3", "1.02_03", "undef", "0"} {
+		pv := MustParse(version)
+		if !pv.Valid() {
+			t.Errorf("NewPerlVersion(%q).Valid() => false, expected "+
+				"true", version)
+		}
+	}
+	invalid := []Version{{}, {original: "v1.-2.3", qv: true,
+		version: []int64{1, -2, 3}}}
+	for _, pv := range invalid {
+		if pv.Valid() {
+			t.Errorf("%#v.Valid() => true, expected false", pv)
+		}
+	}
+	if err := invalid[1].Validate(); err != errNegativeComponent {
+		t.Errorf("%#v.Validate() => %v, expected %v", invalid[1], err,
+			errNegativeComponent)
+	}
+
+	// the decoders won't produce one either
+	var pv Version
+	err := json.Unmarshal([]byte(`{"original":"v1.2.3","qv":true,`+
+		`"version":[1,-2,3]}`), &pv)
+	if err != errNegativeComponent {
+		t.Errorf("json.Unmarshal() with a negative component => %v, "+
+			"expected %v", err, errNegativeComponent)
+	}
+	err = pv.UnmarshalBinary([]byte{2, 0, 3, 2, 3, 6})
+	if err != errNegativeComponent {
+		t.Errorf("Version.UnmarshalBinary() with a negative component "+
+			"=> %v, expected %v", err, errNegativeComponent)
+	}
+	var buf bytes.Buffer
+	_ = gob.NewEncoder(&buf).Encode(gobVersion{Original: "v1.2.3",
+		Qv: true, Version: []int64{1, -2, 3}})
+	if err := pv.GobDecode(buf.Bytes()); err != errNegativeComponent {
+		t.Errorf("Version.GobDecode() with a negative component => %v, "+
+			"expected %v", err, errNegativeComponent)
+	}
+}
+
+func TestVersion_MarshalBinary(t *testing.T) {
+	var _ encoding.BinaryMarshaler = (*Version)(nil)
+	var _ encoding.BinaryUnmarshaler = (*Version)(nil)
+	inputs := []Version{MustParse("undef"), MustParse("1.02_03"),
+		MustParse("v1.2.3"), MustParse(".1.2"),
+		MustParse("v1.2.3.4.5.6.7.8.9.10.11.12.13.14.15.16"),
+		MustParse("v9223372036854775807.1.2"), Undef(), {}}
+	for _, input := range inputs {
+		data, err := input.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Version.MarshalBinary() returned error: %v", err)
+		}
+		var actual Version
+		if err := actual.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Version.UnmarshalBinary(%x) returned error: %v",
+				data, err)
+		}
+		if !reflect.DeepEqual(actual, input) {
+			t.Errorf("Version.UnmarshalBinary(%x) => %#v, expected %#v",
+				data, actual, input)
+		}
+	}
+
+	// the layout is documented, so pin it down
+	pv := MustParse("v1.2_3")
+	data, _ := pv.MarshalBinary()
+	expected := []byte{3, 6, 'v', '1', '.', '2', '_', '3', 3, 2, 46, 0}
+	if !bytes.Equal(data, expected) {
+		t.Errorf("NewPerlVersion(%q).MarshalBinary() => %v, expected %v",
+			"v1.2_3", data, expected)
+	}
+
+	invalid := [][]byte{nil, {4, 0, 0}, {0, 5, 'v'}, {0, 0, 2, 2},
+		{0, 0, 1, 0x80}, {0, 0, 0, 0}, {0, 0x80}}
+	for _, data := range invalid {
+		var actual Version
+		if err := actual.UnmarshalBinary(data); err == nil {
+			t.Errorf("Version.UnmarshalBinary(%v) => nil error, "+
+				"expected error", data)
+		}
+	}
+}
+
+func TestVersion_Bytes(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected []byte
+	}{
+		{"undef", []byte{0, 0, 0, 0, 0, 0, 0, 0}},
+		{"v1.2", []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2}},
+		{"v1.2.0.0", []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2}},
+		{"1.002", []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2}},
+		{"v1.300", []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1, 44}},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		actual := pv.Bytes()
+		if !bytes.Equal(actual, test.expected) {
+			t.Errorf("NewPerlVersion(%q).Bytes() => %v, expected %v",
+				test.version, actual, test.expected)
+		}
+		if again := pv.Bytes(); !bytes.Equal(again, actual) {
+			t.Errorf("NewPerlVersion(%q).Bytes() isn't stable",
+				test.version)
+		}
+	}
+
+	// equal normal forms give equal bytes
+	pairs := [][2]string{{"v1.2", "v1.2.0"}, {"1.2", "v1.200.0"},
+		{"0", "v0.0.0"}, {"1.02_03", "v1.20.300"}}
+	for _, pair := range pairs {
+		a, b := MustParse(pair[0]), MustParse(pair[1])
+		if !bytes.Equal(a.Bytes(), b.Bytes()) {
+			t.Errorf("NewPerlVersion(%q).Bytes() => %v, expected %v",
+				pair[0], a.Bytes(), b.Bytes())
+		}
+	}
+	a, b := MustParse("v1.2.3"), MustParse("v1.2.4")
+	if bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Errorf("NewPerlVersion(%q).Bytes() collides with %q",
+			"v1.2.3", "v1.2.4")
+	}
+}
+
+func TestVersion_Key(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"undef", "v0"},
+		{"0", "v0"},
+		{"v0.0.0", "v0"},
+		{"1.002", "v1.2"},
+		{"v1.2", "v1.2"},
+		{"v1.2.0.0", "v1.2"},
+		{"v1.0.2", "v1.0.2"},
+		{"1.2", "v1.200"},
+		{"v1.2.30", "v1.2.30"},
+		{"v1.2.3_0", "v1.2.30-alpha"},
+		{"1.02_03", "v1.20.300-alpha"},
+	}
+	keys := make(map[string]string)
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		key := pv.Key()
+		if key != test.expected {
+			t.Errorf("NewPerlVersion(%q).Key() => %q, expected %q",
+				test.version, key, test.expected)
+		}
+		if again := MustParse(test.version); again.Key() != key {
+			t.Errorf("NewPerlVersion(%q).Key() isn't deterministic",
+				test.version)
+		}
+		// distinct versions must get distinct keys
+		if other, ok := keys[key]; ok {
+			otherPv := MustParse(other)
+			if !pv.Equal(&otherPv) || pv.alpha != otherPv.alpha {
+				t.Errorf("NewPerlVersion(%q).Key() collides "+
+					"with %q", test.version, other)
+			}
+		}
+		keys[key] = test.version
+	}
+
+	var zero Version
+	if key := zero.Key(); key != "v0" {
+		t.Errorf("Version{}.Key() => %q, expected %q", key, "v0")
+	}
+}
+
+func TestVersion_ValueKey(t *testing.T) {
+	same := [][]string{
+		{"undef", "0", "v0.0.0", "0.000000"},
+		{"1.002", "v1.2", "v1.2.0.0"},
+		{"v1.2.3_0"},
+		{"v1.2.30"},
+		{"v1.2.3.4.5.6.7.8"},
+		{"v1.2.3.4.5.6.7.9"},
+	}
+	keys := make(map[ValueKey]string)
+	for _, group := range same {
+		first := MustParse(group[0])
+		key := first.ValueKey()
+		for _, version := range group[1:] {
+			pv := MustParse(version)
+			if pv.ValueKey() != key {
+				t.Errorf("NewPerlVersion(%q).ValueKey() => %+v, "+
+					"expected %+v", version, pv.ValueKey(),
+					key)
+			}
+		}
+		if other, ok := keys[key]; ok {
+			t.Errorf("NewPerlVersion(%q).ValueKey() collides with %q",
+				group[0], other)
+		}
+		keys[key] = group[0]
+	}
+
+	// past the cap, versions collide
+	a := MustParse("v1.2.3.4.5.6.7.8.9")
+	b := MustParse("v1.2.3.4.5.6.7.8.10")
+	if a.ValueKey() != b.ValueKey() {
+		t.Errorf("ValueKey() didn't truncate past %d components",
+			ValueKeyComponents)
+	}
+}
+
+func TestVersion_CompareAlpha(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int // from CompareStable; Compare ignores the alpha flag
+	}{
+		{"v1.2.3_0", "v1.2.30", -1},
+		{"v1.2.30", "v1.2.3_0", 1},
+		{"v1.2.3_0", "v1.2.3_0", 0},
+		{"1.02_03", "1.0203", -1},
+		{"1.0203", "1.02_03", 1},
+		{"1.02_03", "v1.20.300", -1},
+		// the components still take precedence
+		{"v1.2.3_1", "v1.2.30", 1},
+		{"v1.2.30", "v1.2.3_1", -1},
+	}
+	for _, test := range tests {
+		a := MustParse(test.a)
+		b := MustParse(test.b)
+		if actual := a.CompareStable(&b); actual != test.expected {
+			t.Errorf("%q.CompareStable(%q) => %d, expected %d",
+				test.a, test.b, actual, test.expected)
+		}
+		// Perl's vcmp only sees the components
+		expected := 0
+		if a.LessThan(&b) {
+			expected = -1
+		} else if a.GreaterThan(&b) {
+			expected = 1
+		}
+		if actual := a.Compare(&b); actual != expected {
+			t.Errorf("%q.Compare(%q) => %d, expected %d", test.a,
+				test.b, actual, expected)
+		}
+	}
+	a, b := MustParse("v1.2.3_0"), MustParse("v1.2.30")
+	if a.Compare(&b) != 0 || !a.Equal(&b) {
+		t.Errorf("%q.Compare(%q) => %d, expected 0, as Equal",
+			"v1.2.3_0", "v1.2.30", a.Compare(&b))
+	}
+}
+
+func TestFromNumify(t *testing.T) {
+	tests := []struct {
+		input    float64
+		expected []int64
+	}{
+		{0, []int64{0}},
+		{42, []int64{42}},
+		{1.2, []int64{1, 200}},
+		{1.002, []int64{1, 2}},
+		{1.002003, []int64{1, 2, 3}},
+		{1.00203, []int64{1, 2, 30}},
+		{5.010001, []int64{5, 10, 1}},
+		// trailing zero components are lost
+		{1.002000, []int64{1, 2}},
+		// and past float64 precision, so are the components
+		{1.002003004005006007, []int64{1, 2, 3, 4, 5, 6}},
+	}
+	for _, test := range tests {
+		pv := FromNumify(test.input)
+		if !reflect.DeepEqual(pv.version, test.expected) {
+			t.Errorf("FromNumify(%v).version => %v, expected %v",
+				test.input, pv.version, test.expected)
+		}
+		if pv.IsQv() {
+			t.Errorf("FromNumify(%v).IsQv() => true, expected false",
+				test.input)
+		}
+		if actual := pv.Numify(); actual != test.input {
+			t.Errorf("FromNumify(%v).Numify() => %v", test.input,
+				actual)
+		}
+	}
+
+	for _, input := range []float64{-1, math.NaN(), math.Inf(1), 1e20} {
+		if pv := FromNumify(input); pv.Raw() != "undef" {
+			t.Errorf("FromNumify(%v) => %q, expected %q", input,
+				pv.Raw(), "undef")
+		}
+	}
+}
+
+func TestVersion_Canonical(t *testing.T) {
+	versions := []string{".1", ".1.2", "0", "0.123", "01.0203", "1.",
+		"1.02_03", "1.2.3", "42", "undef", "v1", "v1.2", "v1.2.3.4",
+		"v1.2.3_0", "v1.2345.6"}
+	for _, version := range versions {
+		pv := MustParse(version)
+		canonical := pv.Canonical()
+		if canonical.Raw() != pv.Normal() {
+			t.Errorf("NewPerlVersion(%q).Canonical().Raw() => %q, "+
+				"expected %q", version, canonical.Raw(),
+				pv.Normal())
+		}
+		if !canonical.IsQv() || len(canonical.version) < 3 {
+			t.Errorf("NewPerlVersion(%q).Canonical() => %+v, isn't "+
+				"in normal form", version, canonical)
+		}
+		if reparsed := MustParse(pv.Normal()); !reflect.DeepEqual(
+			canonical, reparsed) {
+			t.Errorf("NewPerlVersion(%q).Canonical() => %+v, "+
+				"expected %+v", version, canonical, reparsed)
+		}
+		for _, other := range versions {
+			otherPv := MustParse(other)
+			if pv.LessThan(&otherPv) != canonical.LessThan(&otherPv) ||
+				pv.Equal(&otherPv) != canonical.Equal(&otherPv) {
+				t.Errorf("NewPerlVersion(%q).Canonical() "+
+					"compares differently to %q", version,
+					other)
+			}
+		}
+	}
+}
+
+func TestIsStrictDotted(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{"v1.2.3", true},
+		{"v0.0.0", true},
+		{"v1.2.3.4", true},
+		{"v1.2", false},
+		{"v1", false},
+		{"1.2.3", false},
+		{"1.02", false},
+		{"v1.2.3_4", false},
+		{"v1.2345.6", false},
+		{"v01.2.3", false},
+	}
+	for _, test := range tests {
+		if actual := IsStrictDotted(test.version); actual !=
+			test.expected {
+			t.Errorf("IsStrictDotted(%q) => %t, expected %t",
+				test.version, actual, test.expected)
+		}
+	}
+
+	for _, version := range []string{"v1.2", "v1"} {
+		_, err := ParseStrict(version)
+		if err == nil || !strings.Contains(err.Error(), "three") {
+			t.Errorf("ParseStrict(%q) => %v, expected an error "+
+				"about needing three components", version, err)
+		}
+	}
+	if _, err := ParseStrict("v1.2.3"); err != nil {
+		t.Errorf("ParseStrict(%q) returned error: %v", "v1.2.3", err)
+	}
+}
+
+func TestVersion_Decimal(t *testing.T) {
+	// these are the results of Perl's numify
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{".1", "0.100"},
+		{".1.2", "0.001002"},
+		{"0", "0.000"},
+		{"0.0", "0.000"},
+		{"0.123", "0.123"},
+		{"01", "1.000"},
+		{"01.0203", "1.020300"},
+		{"1.", "1.000"},
+		{"1.00", "1.000"},
+		{"1.00001", "1.000010"},
+		{"1.002", "1.002"},
+		{"1.002003", "1.002003"},
+		{"1.00203", "1.002030"},
+		{"1.0023", "1.002300"},
+		{"1.02", "1.020"},
+		{"1.0203", "1.020300"},
+		{"1.02_03", "1.020300"},
+		{"1.2", "1.200"},
+		{"1.2.3", "1.002003"},
+		{"1.2345_01", "1.234501"},
+		{"12.345", "12.345"},
+		{"42", "42.000"},
+		{"undef", "0.000"},
+		{"v0", "0.000000"},
+		{"v0.0.0", "0.000000"},
+		{"v0.1.2", "0.001002"},
+		{"v01", "1.000000"},
+		{"v01.02.03", "1.002003"},
+		{"v1", "1.000000"},
+		{"v1.02_03", "1.203000"},
+		{"v1.2", "1.002000"},
+		{"v1.2.3", "1.002003"},
+		{"v1.2.3.4", "1.002003004"},
+		{"v1.2.30", "1.002030"},
+		{"v1.2.3_0", "1.002030"},
+		{"v1.2345.6", "1.2345006"},
+		{"v1.2_3", "1.023000"},
+		{"1.11111111111", "1.111111111110"},
+		{"2147483647.000", "2147483647.000"},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		if actual := pv.Decimal(); actual != test.expected {
+			t.Errorf("NewPerlVersion(%q).Decimal() => %q, expected "+
+				"%q", test.version, actual, test.expected)
+		}
+	}
+}
+
+func TestParseOverflow(t *testing.T) {
+	huge := "99999999999999999999"
+	versions := []string{
+		huge,
+		huge + ".1",
+		huge + ".1_2",
+		"v" + huge,
+		"v" + huge + ".2.3",
+		"v1." + huge + ".3",
+		"v1.2." + huge,
+		"v1.2_" + huge,
+		"1.2." + huge,
+		huge + ".2.3",
+		"." + huge + ".2",
+	}
+	for _, version := range versions {
+		_, err := Parse(version)
+		if !errors.Is(err, errIntegerOverflow) {
+			t.Errorf("Parse(%q) => %v, expected %v", version, err,
+				errIntegerOverflow)
+		}
+		if _, reason := Explain(version); reason !=
+			ReasonIntegerOverflow {
+			t.Errorf("Explain(%q) => %q, expected %q", version,
+				reason, ReasonIntegerOverflow)
+		}
+	}
+
+	// right at the limit is fine
+	pv, err := Parse("9223372036854775807")
+	if err != nil {
+		t.Fatalf("Parse(%q) returned error: %v", "9223372036854775807",
+			err)
+	}
+	if pv.version[0] != math.MaxInt64 {
+		t.Errorf("Parse(%q).version[0] => %d, expected %d",
+			"9223372036854775807", pv.version[0], int64(math.MaxInt64))
+	}
+	if _, err := Parse("9223372036854775808"); err == nil {
+		t.Errorf("Parse(%q) => nil error, expected error",
+			"9223372036854775808")
+	}
+}
+
+// parseBoundaryInputs are inputs at the edges of what the regexes accept, for
+// making sure Parse never panics.
+var parseBoundaryInputs = []string{"", ".", "_", "v", "v.", "v_", "._", "_1",
+	"1_", "1._", "1_0", "1._0", ".1_", ".1_2", "v1_", "v1._2", "v1.2_",
+	"v1.2_3_4", "1.2.3_", "1..2", "v1..2", "..1", "1.2.3.", "v1.2.3.",
+	"undef_1", "vundef", "00", "v00.00.00", "0.0.0.0.0.0.0.0.0.0",
+	"1.99999999999999999999", "1.2_99999999999999999999",
+	"v1.99999999999999999999", "99999999999999999999.1",
+	"\x00", "\u00e9", "1.2\n", " 1.2", "1.2 ", "\t", "v1.2.3\x00"}
+
+func TestParseNoPanic(t *testing.T) {
+	for _, version := range parseBoundaryInputs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("Parse(%q) panicked: %v",
+						version, r)
+				}
+			}()
+			pv, err := Parse(version)
+			if err == nil && !IsLax(version) {
+				t.Errorf("Parse(%q) => %+v, but it isn't a "+
+					"lax version", version, pv)
+			}
+			_, _ = ParseStrict(version)
+			_, _ = ParseLax(version)
+		}()
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, version := range parseBoundaryInputs {
+		f.Add(version)
+	}
+	f.Add("0E0")
+	f.Add("0 but true")
+	f.Fuzz(func(t *testing.T, version string) {
+		// the zero-but-true forms are the only non-lax strings the
+		// option lets through
+		if _, err := Parse(version, WithZeroButTrue()); err == nil &&
+			!IsLax(version) && !isZeroButTrue(version) {
+			t.Errorf("Parse(%q, WithZeroButTrue()) accepted a "+
+				"non-lax version", version)
+		}
+		pv, err := Parse(version)
+		for _, opts := range [][]Option{nil, {WithLaxOnly()}} {
+			bpv, berr := ParseBytes([]byte(version), opts...)
+			spv, serr := Parse(version, opts...)
+			if fmt.Sprint(berr) != fmt.Sprint(serr) ||
+				!reflect.DeepEqual(bpv, spv) {
+				t.Errorf("ParseBytes(%q) => %+v, %v, expected %+v, %v",
+					version, bpv, berr, spv, serr)
+			}
+		}
+		if err != nil {
+			return
+		}
+		if !IsLax(version) {
+			t.Errorf("Parse(%q) => %+v, but it isn't a lax version",
+				version, pv)
+		}
+		if len(pv.version) == 0 {
+			t.Errorf("Parse(%q) => %+v, with no components",
+				version, pv)
+		}
+	})
+}
+
+func TestVersion_IsUndef(t *testing.T) {
+	tests := []struct {
+		version  Version
+		expected bool
+	}{
+		{MustParse("undef"), true},
+		{Undef(), true},
+		{Version{}, true},
+		{MustParse("0"), false},
+		{MustParse("v0.0.0"), false},
+		{MustParse("v1.2.3"), false},
+		{MustParse("1.02_03"), false},
+	}
+	for _, test := range tests {
+		if actual := test.version.IsUndef(); actual != test.expected {
+			t.Errorf("%+v.IsUndef() => %t, expected %t",
+				test.version, actual, test.expected)
+		}
+	}
+}
+
+func TestVersion_ZeroValue(t *testing.T) {
+	var zero Version
+	parsedZero := MustParse("0")
+	standard := MustParse("v1.2.3")
+
+	if !zero.Equal(&parsedZero) || zero.Compare(&parsedZero) != 0 {
+		t.Errorf("Version{} isn't equal to %q", "0")
+	}
+	if !parsedZero.Equal(&zero) || parsedZero.Compare(&zero) != 0 {
+		t.Errorf("%q isn't equal to Version{}", "0")
+	}
+	if zero.Equal(&standard) || !zero.LessThan(&standard) ||
+		zero.Compare(&standard) != -1 {
+		t.Errorf("Version{} isn't less than %q", "v1.2.3")
+	}
+	if standard.Equal(&zero) || !standard.GreaterThan(&zero) ||
+		standard.Compare(&zero) != 1 {
+		t.Errorf("%q isn't greater than Version{}", "v1.2.3")
+	}
+
+	tests := []struct {
+		name     string
+		actual   string
+		expected string
+	}{
+		{"Stringify", zero.Stringify(), parsedZero.Stringify()},
+		{"Normal", zero.Normal(), parsedZero.Normal()},
+		{"NumifyString", zero.NumifyString(), parsedZero.NumifyString()},
+		{"Decimal", zero.Decimal(), parsedZero.Decimal()},
+		{"Key", zero.Key(), parsedZero.Key()},
+	}
+	for _, test := range tests {
+		if test.actual != test.expected {
+			t.Errorf("Version{}.%s() => %q, expected %q", test.name,
+				test.actual, test.expected)
+		}
+	}
+	if zero.Numify() != 0 {
+		t.Errorf("Version{}.Numify() => %v, expected 0", zero.Numify())
+	}
+}
+
+func TestVersion_Validate(t *testing.T) {
+	for _, version := range []string{"undef", ".1.2", "1.", "1.02_03",
+		"v1.2.3.4", "v1.2_3"} {
+		pv := MustParse(version)
+		if err := pv.Validate(); err != nil {
+			t.Errorf("NewPerlVersion(%q).Validate() returned error: "+
+				"%v", version, err)
+		}
+	}
+	undef := Undef()
+	if err := undef.Validate(); err != nil {
+		t.Errorf("Undef().Validate() returned error: %v", err)
+	}
+	var zero Version
+	if err := zero.Validate(); err == nil {
+		t.Errorf("Version{}.Validate() => nil error, expected error")
+	}
+
+	tampered := []string{
+		`{"original":"v1.2.3","alpha":false,"qv":true,"version":[1,2,4]}`,
+		`{"original":"v1.2.3","alpha":false,"qv":true,"version":[1,2]}`,
+		`{"original":"v1.2.3","alpha":true,"qv":true,"version":[1,2,3]}`,
+		`{"original":"v1.2.3","alpha":false,"qv":false,"version":[1,2,3]}`,
+		`{"original":"foo","alpha":false,"qv":true,"version":[1,2,3]}`,
+		`{"original":"v1.2.3","alpha":false,"qv":true,"version":[]}`,
+	}
+	for _, data := range tampered {
+		var pv Version
+		if err := json.Unmarshal([]byte(data), &pv); err != nil {
+			t.Fatalf("Version.UnmarshalJSON(%s) returned error: %v",
+				data, err)
+		}
+		if err := pv.Validate(); err == nil {
+			t.Errorf("Version.Validate() of %s => nil error, "+
+				"expected error", data)
+		}
+	}
+}
+
+func TestVersion_UnmarshalJSONString(t *testing.T) {
+	for _, version := range []string{"undef", ".1.2", "1.02_03",
+		"v1.2.3"} {
+		expected := MustParse(version)
+		object, err := expected.MarshalJSONVerbose()
+		if err != nil {
+			t.Fatalf("Version.MarshalJSON() returned error: %v", err)
+		}
+		str, err := json.Marshal(version)
+		if err != nil {
+			t.Fatalf("json.Marshal(%q) returned error: %v", version,
+				err)
+		}
+		for _, data := range [][]byte{object, str} {
+			var actual Version
+			if err := json.Unmarshal(data, &actual); err != nil {
+				t.Errorf("Version.UnmarshalJSON(%s) returned "+
+					"error: %v", data, err)
+				continue
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("Version.UnmarshalJSON(%s) => %+v, "+
+					"expected %+v", data, actual, expected)
+			}
+		}
+	}
+
+	var wrapper struct {
+		Version Version `json:"version"`
+	}
+	err := json.Unmarshal([]byte(`{"version": "v1.2.3"}`), &wrapper)
+	if err != nil {
+		t.Fatalf("Version.UnmarshalJSON() returned error: %v", err)
+	}
+	if wrapper.Version.Raw() != "v1.2.3" {
+		t.Errorf("Version.UnmarshalJSON() => %q, expected %q",
+			wrapper.Version.Raw(), "v1.2.3")
+	}
+
+	var pv Version
+	if err := json.Unmarshal([]byte(`"foo"`), &pv); err == nil {
+		t.Errorf("Version.UnmarshalJSON(%s) => nil error, expected "+
+			"error", `"foo"`)
+	}
+}
+
+func TestVersion_MarshalJSONString(t *testing.T) {
+	tests := []struct {
+		version  Version
+		expected string
+	}{
+		{MustParse("v1.2.3"), `"v1.2.3"`},
+		{MustParse("1.02_03"), `"1.02_03"`},
+		{MustParse(".1"), `".1"`},
+		{MustParse("undef"), `"0"`},
+		{Version{}, `"0"`},
+	}
+	for _, test := range tests {
+		data, err := json.Marshal(&test.version)
+		if err != nil {
+			t.Fatalf("Version.MarshalJSON() returned error: %v", err)
+		}
+		if string(data) != test.expected {
+			t.Errorf("Version.MarshalJSON() => %s, expected %s",
+				data, test.expected)
+		}
+		var actual Version
+		if err := json.Unmarshal(data, &actual); err != nil {
+			t.Fatalf("Version.UnmarshalJSON(%s) returned error: %v",
+				data, err)
+		}
+		if !actual.Equal(&test.version) ||
+			actual.Stringify() != test.version.Stringify() {
+			t.Errorf("Version.UnmarshalJSON(%s) => %+v, expected "+
+				"%+v", data, actual, test.version)
+		}
+	}
+}
+
+func TestVersion_MarshalJSONValue(t *testing.T) {
+	var _ json.Marshaler = Version{}
+	pv := MustParse("v1.2.3")
+	data, err := json.Marshal(map[string]Version{"perl": pv})
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+	if string(data) != `{"perl":"v1.2.3"}` {
+		t.Errorf("json.Marshal() of a map value => %s, expected %s",
+			data, `{"perl":"v1.2.3"}`)
+	}
+	data, err = json.Marshal(struct{ V Version }{pv})
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+	if string(data) != `{"V":"v1.2.3"}` {
+		t.Errorf("json.Marshal() of a struct field => %s, expected %s",
+			data, `{"V":"v1.2.3"}`)
+	}
+	var decoded struct{ V Version }
+	if err := json.Unmarshal(data, &decoded); err != nil ||
+		!decoded.V.Identical(&pv) {
+		t.Errorf("json.Unmarshal(%s) => %+v, %v, expected %+v", data,
+			decoded.V, err, pv)
+	}
+}
+
+func TestVersion_MarshalJSONVerbose(t *testing.T) {
+	for _, version := range []string{"undef", ".1.2", "1.02_03",
+		"v1.2.3"} {
+		input := MustParse(version)
+		data, err := input.MarshalJSONVerbose()
+		if err != nil {
+			t.Fatalf("Version.MarshalJSONVerbose() returned error: "+
+				"%v", err)
+		}
+		var actual Version
+		if err := json.Unmarshal(data, &actual); err != nil {
+			t.Fatalf("Version.UnmarshalJSON(%s) returned error: %v",
+				data, err)
+		}
+		if !reflect.DeepEqual(actual, input) {
+			t.Errorf("Version.UnmarshalJSON(%s) => %+v, expected "+
+				"%+v", data, actual, input)
+		}
+	}
+}
+
+func TestVersion_CompareString(t *testing.T) {
+	tests := []struct {
+		version  string
+		other    string
+		expected int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.2.3", "v1.2.4", -1},
+		{"v1.2.3", "v1.2", 1},
+		{"v1.2", "v1.2.0", 0},
+		{"1.02_03", "1.0203", 0},
+		{"undef", "0", 0},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		actual, err := pv.CompareString(test.other)
+		if err != nil {
+			t.Errorf("%q.CompareString(%q) returned error: %v",
+				test.version, test.other, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("%q.CompareString(%q) => %d, expected %d",
+				test.version, test.other, actual, test.expected)
+		}
+	}
+
+	pv := MustParse("v1.2.3")
+	for _, other := range []string{"", "foo", "1_0"} {
+		if _, err := pv.CompareString(other); err == nil {
+			t.Errorf("%q.CompareString(%q) => nil error, expected "+
+				"error", "v1.2.3", other)
+		}
+	}
+}
+
+func TestStringComparisons(t *testing.T) {
+	tests := []struct {
+		a       string
+		b       string
+		compare int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.2", "v1.2.0", 0},
+		{"v1.2.3", "v1.2.4", -1},
+		{"v1.2.4", "v1.2.3", 1},
+		{"1.02", "v1.20.0", 0},
+		{"undef", "v0.0.1", -1},
+		{"1.02_03", "1.0203", 0},
+	}
+	for _, test := range tests {
+		compare, err := CompareStrings(test.a, test.b)
+		if err != nil || compare != test.compare {
+			t.Errorf("CompareStrings(%q, %q) => (%d, %v), expected "+
+				"(%d, nil)", test.a, test.b, compare, err,
+				test.compare)
+		}
+		a := MustParse(test.a)
+		b := MustParse(test.b)
+		equal, err := EqualStrings(test.a, test.b)
+		if err != nil || equal != a.Equal(&b) {
+			t.Errorf("EqualStrings(%q, %q) => (%t, %v), expected "+
+				"(%t, nil)", test.a, test.b, equal, err,
+				a.Equal(&b))
+		}
+		less, err := LessThanStrings(test.a, test.b)
+		if err != nil || less != a.LessThan(&b) {
+			t.Errorf("LessThanStrings(%q, %q) => (%t, %v), expected "+
+				"(%t, nil)", test.a, test.b, less, err,
+				a.LessThan(&b))
+		}
+	}
+
+	for _, pair := range [][2]string{{"foo", "v1.2.3"}, {"v1.2.3", "foo"},
+		{"", ""}} {
+		if _, err := CompareStrings(pair[0], pair[1]); err == nil {
+			t.Errorf("CompareStrings(%q, %q) => nil error, expected "+
+				"error", pair[0], pair[1])
+		}
+		if _, err := EqualStrings(pair[0], pair[1]); err == nil {
+			t.Errorf("EqualStrings(%q, %q) => nil error, expected "+
+				"error", pair[0], pair[1])
+		}
+		if _, err := LessThanStrings(pair[0], pair[1]); err == nil {
+			t.Errorf("LessThanStrings(%q, %q) => nil error, "+
+				"expected error", pair[0], pair[1])
+		}
+	}
+}
+
+func TestDeclare(t *testing.T) {
+	// the normal forms are from Perl's version->declare
+	tests := []struct {
+		version  string
+		original string
+		normal   string
+		alpha    bool
+	}{
+		{"1.2", "v1.2", "v1.2.0", false},
+		{"1", "v1", "v1.0.0", false},
+		{"0", "v0", "v0.0.0", false},
+		{"1.", "v1", "v1.0.0", false},
+		{"1.02", "v1.02", "v1.2.0", false},
+		{"12.345", "v12.345", "v12.345.0", false},
+		{"01.0203", "v01.0203", "v1.203.0", false},
+		{".1", "v0.1", "v0.1.0", false},
+		{"1.02_03", "v1.02_03", "v1.203.0", true},
+		{"1.2_3", "v1.2_3", "v1.23.0", true},
+		{"1.2345_01", "v1.2345_01", "v1.234501.0", true},
+		{"1.2.3", "1.2.3", "v1.2.3", false},
+		{"1.2.3.4", "1.2.3.4", "v1.2.3.4", false},
+		{"v1.2", "v1.2", "v1.2.0", false},
+		{"undef", "undef", "v0.0.0", false},
+	}
+	for _, test := range tests {
+		pv, err := Declare(test.version)
+		if err != nil {
+			t.Errorf("Declare(%q) returned error: %v", test.version,
+				err)
+			continue
+		}
+		if pv.Raw() != test.original || pv.Normal() != test.normal ||
+			pv.IsAlpha() != test.alpha {
+			t.Errorf("Declare(%q) => %+v, expected original %q, "+
+				"normal %q and alpha %t", test.version, pv,
+				test.original, test.normal, test.alpha)
+		}
+		if !pv.IsQv() && !pv.IsUndef() {
+			t.Errorf("Declare(%q).IsQv() => false, expected true",
+				test.version)
+		}
+	}
+
+	declared, err := Declare("1.2")
+	if err != nil {
+		t.Fatalf("Declare(%q) returned error: %v", "1.2", err)
+	}
+	if expected := MustParse("v1.2"); !reflect.DeepEqual(declared,
+		expected) {
+		t.Errorf("Declare(%q) => %+v, expected %+v", "1.2", declared,
+			expected)
+	}
+
+	for _, version := range []string{"", "foo", "1_0"} {
+		if _, err := Declare(version); err == nil {
+			t.Errorf("Declare(%q) => nil error, expected error",
+				version)
+		}
+	}
+}
+
+func TestParsePerlConfig(t *testing.T) {
+	tests := []struct {
+		config   string
+		expected []int64
+	}{
+		{"version='5.34.0';", []int64{5, 34, 0}},
+		{"version='5.34.0';\n", []int64{5, 34, 0}},
+		{`version="5.8.9";`, []int64{5, 8, 9}},
+		{"'5.36.1'", []int64{5, 36, 1}},
+		{"5.34.0", []int64{5, 34, 0}},
+		{"5.034000", []int64{5, 34, 0}},
+		{"5.008009\n", []int64{5, 8, 9}},
+		{"v5.38.2", []int64{5, 38, 2}},
+	}
+	for _, test := range tests {
+		pv, err := ParsePerlConfig(test.config)
+		if err != nil {
+			t.Errorf("ParsePerlConfig(%q) returned error: %v",
+				test.config, err)
+			continue
+		}
+		if !reflect.DeepEqual(pv.version, test.expected) {
+			t.Errorf("ParsePerlConfig(%q).version => %v, expected %v",
+				test.config, pv.version, test.expected)
+		}
+	}
+
+	for _, config := range []string{"", "version='';", "version='5.34.0\";",
+		"osname='linux';", "version=5.34.0'"} {
+		if _, err := ParsePerlConfig(config); err == nil {
+			t.Errorf("ParsePerlConfig(%q) => nil error, expected error",
+				config)
+		}
+	}
+}
+
+func TestVersion_NumComponents(t *testing.T) {
+	tests := []struct {
+		version     string
+		num         int
+		significant int
+	}{
+		{"v1", 3, 1},
+		{"v1.2", 3, 2},
+		{"v1.2.0", 3, 2},
+		{"v1.2.3", 3, 3},
+		{"v1.0.3", 3, 3},
+		{"v1.2.3.0", 4, 3},
+		{"v0.0.0", 3, 1},
+		{"1", 1, 1},
+		{"1.2", 2, 2},
+		{"1.000", 2, 1},
+		{"1.002000", 3, 2},
+		{"1.", 2, 1},
+		{"undef", 1, 1},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		if actual := pv.NumComponents(); actual != test.num {
+			t.Errorf("NewPerlVersion(%q).NumComponents() => %d, "+
+				"expected %d", test.version, actual, test.num)
+		}
+		if actual := pv.SignificantComponents(); actual !=
+			test.significant {
+			t.Errorf("NewPerlVersion(%q).SignificantComponents() "+
+				"=> %d, expected %d", test.version, actual,
+				test.significant)
+		}
+	}
+
+	var zero Version
+	if zero.NumComponents() != 0 || zero.SignificantComponents() != 1 {
+		t.Errorf("Version{} => (%d, %d) components, expected (0, 1)",
+			zero.NumComponents(), zero.SignificantComponents())
+	}
+}
+
+func TestVersion_Trimmed(t *testing.T) {
+	tests := []struct {
+		version  string
+		original string
+		expected []int64
+	}{
+		{"v1.2.0.0", "v1.2", []int64{1, 2, 0}},
+		{"v1.2.3", "v1.2.3", []int64{1, 2, 3}},
+		{"v1", "v1", []int64{1, 0, 0}},
+		{"v0.0.0", "v0", []int64{0, 0, 0}},
+		{"v1.0.2.0", "v1.0.2", []int64{1, 0, 2}},
+		{"1.200000", "1.200", []int64{1, 200}},
+		{"1.000", "1", []int64{1}},
+		{"1.", "1", []int64{1}},
+		{"1.002003", "1.002003", []int64{1, 2, 3}},
+		{"undef", "0", []int64{0}},
+		{"v1.2_3", "v1.23", []int64{1, 23, 0}},
+		{"v1.2.3_4", "v1.2.34", []int64{1, 2, 34}},
+		{"1.02_03", "1.020300", []int64{1, 20, 300}},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		trimmed := pv.Trimmed()
+		if trimmed.Raw() != test.original ||
+			!reflect.DeepEqual(trimmed.version, test.expected) {
+			t.Errorf("NewPerlVersion(%q).Trimmed() => %+v, expected "+
+				"original %q and components %v", test.version,
+				trimmed, test.original, test.expected)
+		}
+		if !trimmed.Equal(&pv) {
+			t.Errorf("NewPerlVersion(%q).Trimmed() isn't equal to "+
+				"the original", test.version)
+		}
+		if trimmed.IsAlpha() || trimmed.IsQv() != pv.IsQv() {
+			t.Errorf("NewPerlVersion(%q).Trimmed() => alpha %t, qv "+
+				"%t, expected false, %t", test.version,
+				trimmed.IsAlpha(), trimmed.IsQv(), pv.IsQv())
+		}
+		// it has to be consistent, even through a JSON string
+		if err := trimmed.Validate(); err != nil {
+			t.Errorf("NewPerlVersion(%q).Trimmed().Validate() => %v, "+
+				"expected nil", test.version, err)
+		}
+		data, _ := json.Marshal(trimmed.Stringify())
+		var decoded Version
+		if err := json.Unmarshal(data, &decoded); err != nil ||
+			!decoded.Identical(&trimmed) {
+			t.Errorf("NewPerlVersion(%q).Trimmed() through JSON => "+
+				"%#v, %v, expected %#v", test.version, decoded, err,
+				trimmed)
+		}
+		if reparsed := MustParse(trimmed.Raw()); !reparsed.Equal(&pv) {
+			t.Errorf("NewPerlVersion(%q).Trimmed().Raw() => %q, "+
+				"which isn't equal to the original",
+				test.version, trimmed.Raw())
+		}
+	}
+
+	pv := MustParse("v1.2.0")
+	_ = pv.Trimmed()
+	if !reflect.DeepEqual(pv.version, []int64{1, 2, 0}) {
+		t.Errorf("Trimmed() modified the receiver: %+v", pv)
+	}
+}
+
+func TestRegexpGetters(t *testing.T) {
+	tests := []struct {
+		version string
+		lax     bool
+		strict  bool
+	}{
+		{".1", true, false},
+		{".1.2", true, false},
+		{"0", true, true},
+		{"0.123", true, true},
+		{"01.0203", true, false},
+		{"1.", true, false},
+		{"1.02_03", true, false},
+		{"1.2.3", true, false},
+		{"12.345", true, true},
+		{"undef", true, false},
+		{"v0.1.2", true, true},
+		{"v1", true, false},
+		{"v1.02_03", true, false},
+		{"v1.2.3", true, true},
+		{"v1.2.3.4", true, true},
+		{"v1.2345.6", true, false},
+		{"foo", false, false},
+	}
+	lax := LaxRegexp()
+	strict := StrictRegexp()
+	for _, test := range tests {
+		laxMatch := lax.FindString(test.version) == test.version
+		if laxMatch != test.lax {
+			t.Errorf("LaxRegexp() matching %q => %t, expected %t",
+				test.version, laxMatch, test.lax)
+		}
+		strictMatch := strict.FindString(test.version) == test.version
+		if strictMatch != test.strict {
+			t.Errorf("StrictRegexp() matching %q => %t, expected %t",
+				test.version, strictMatch, test.strict)
+		}
+	}
+	if lax.String() != LaxVersionRegex {
+		t.Errorf("LaxRegexp().String() => %q, expected %q",
+			lax.String(), LaxVersionRegex)
+	}
+	if strict.String() != StrictVersionRegex {
+		t.Errorf("StrictRegexp().String() => %q, expected %q",
+			strict.String(), StrictVersionRegex)
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"", nil},
+		{"no versions here", nil},
+		{"v1.2.3", []string{"v1.2.3"}},
+		{"Changes for Foo-Bar\n\n1.02 2020-01-01\n  - fixed a bug " +
+			"introduced in v1.0.1.\n\n1.01_01 was a trial release, " +
+			"and requires perl 5.008009 or undef.",
+			[]string{"1.02", "2020", "01", "01", "v1.0.1", "1.01_01",
+				"5.008009", "undef"}},
+		{"(1.2), [v3.4.5]; 6.7_8!", []string{"1.2", "v3.4.5", "6.7_8"}},
+		{"undefined x86 foo1.2 1.2rc 1.2_", nil},
+		{"1.2 1.3\t1.4\n1.5", []string{"1.2", "1.3", "1.4", "1.5"}},
+		{"1_0 is not a version", []string{}},
+	}
+	for _, test := range tests {
+		found := FindAll(test.text)
+		actual := make([]string, len(found))
+		for i, pv := range found {
+			actual[i] = pv.Raw()
+		}
+		if len(actual) != len(test.expected) ||
+			(len(actual) > 0 && !reflect.DeepEqual(actual,
+				test.expected)) {
+			t.Errorf("FindAll(%q) => %q, expected %q", test.text,
+				actual, test.expected)
+		}
+	}
+}
+
+func TestUnanchoredRegex(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		text     string
+		expected []string
+	}{
+		{LaxVersionRegexUnanchored, "requires v5.10.1 or 5.008_001",
+			[]string{"v5.10.1", "5.008_001"}},
+		{LaxVersionRegexUnanchored, "nothing here", nil},
+		{StrictVersionRegexUnanchored, "Foo-Bar-1.02.tar.gz",
+			[]string{"1.02"}},
+		{StrictVersionRegexUnanchored, "use v5.36.0;", []string{"v5.36.0"}},
+	}
+	for _, test := range tests {
+		re := regexp.MustCompile(test.pattern)
+		re.Longest()
+		actual := re.FindAllString(test.text, -1)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("FindAllString(%q) => %q, expected %q", test.text,
+				actual, test.expected)
+		}
+	}
+	anchored := regexp.MustCompile("^" + StrictVersionRegex)
+	if anchored.MatchString("v1.2.3 trailing") {
+		t.Errorf("StrictVersionRegex matched text with a trailing suffix")
+	}
+}
+
+func TestCmpValues(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.2", "v1.200.0", 0},
+		{"1.2", "v1.300.0", -1},
+		{"v1.300.0", "1.2", 1},
+		{"v1.2.3_0", "v1.2.30", 0},
+		{"undef", "0", 0},
+	}
+	for _, test := range tests {
+		if actual := MustParse(test.a).Cmp(MustParse(test.b)); actual !=
+			test.expected {
+			t.Errorf("MustParse(%q).Cmp(MustParse(%q)) => %d, expected %d",
+				test.a, test.b, actual, test.expected)
+		}
+		if actual := MustParse(test.a).Less(MustParse(test.b)); actual !=
+			(test.expected < 0) {
+			t.Errorf("MustParse(%q).Less(MustParse(%q)) => %v, expected %v",
+				test.a, test.b, actual, test.expected < 0)
+		}
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	var _ flag.Value = (*Version)(nil)
+	var nilPv *Version
+	if actual := nilPv.String(); actual != "" {
+		t.Errorf("(*Version)(nil).String() => %q, expected \"\"", actual)
+	}
+
+	var pv Version
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&pv, "min-version", "minimum version")
+	if err := fs.Parse([]string{"-min-version", "v1.2.3"}); err != nil {
+		t.Fatalf("FlagSet.Parse() => %v, expected nil", err)
+	}
+	if pv.String() != "v1.2.3" || pv.Cmp(MustParse("1.2.3")) != 0 {
+		t.Errorf("-min-version v1.2.3 => %q, expected %q", pv.String(),
+			"v1.2.3")
+	}
+	if err := fs.Parse([]string{"-min-version", "1.2_3_4"}); err == nil {
+		t.Errorf("-min-version 1.2_3_4 => nil, expected error")
+	}
+	if actual := pv.Type(); actual != "perlversion" {
+		t.Errorf("Version.Type() => %q, expected %q", actual,
+			"perlversion")
+	}
+	if pv.String() != "v1.2.3" {
+		t.Errorf("version after a failed Set => %q, expected %q",
+			pv.String(), "v1.2.3")
+	}
+}
+
+// Run with -race to check the methods really are read-only.
+func TestConcurrentComparisons(t *testing.T) {
+	shared := MustParse("v1.2.3")
+	others := []Version{MustParse("1.2"), MustParse("v1.2.3_4"),
+		MustParse("v1.2.3"), MustParse("2"), Version{}}
+	var wg sync.WaitGroup
+	errs := make(chan string, 64)
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(other Version) {
+			defer wg.Done()
+			copied := shared
+			for j := 0; j < 100; j++ {
+				cmp := shared.Compare(&other)
+				if shared.LessThan(&other) && cmp >= 0 {
+					errs <- "LessThan disagrees with Compare"
+					return
+				}
+				if copied.Compare(&shared) != 0 || shared.Numify() != 1.002003 ||
+					shared.Normal() != "v1.2.3" {
+					errs <- "shared version changed"
+					return
+				}
+			}
+		}(others[i%len(others)])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestLeadingDot(t *testing.T) {
+	// the expected values are from Perl's version->parse
+	tests := []struct {
+		version    string
+		components []int64
+		normal     string
+		qv         bool
+	}{
+		{".0", []int64{0, 0}, "v0.0.0", false},
+		{".00", []int64{0, 0}, "v0.0.0", false},
+		{".1", []int64{0, 100}, "v0.100.0", false},
+		{".123456", []int64{0, 123, 456}, "v0.123.456", false},
+		{".1_2", []int64{0, 120}, "v0.120.0", false},
+		{".0.0", []int64{0, 0, 0}, "v0.0.0", true},
+		{".1.2", []int64{0, 1, 2}, "v0.1.2", true},
+		{".1.2.3", []int64{0, 1, 2, 3}, "v0.1.2.3", true},
+		{".1.2_3", []int64{0, 1, 23}, "v0.1.23", true},
+	}
+	for _, test := range tests {
+		pv, err := Parse(test.version)
+		if err != nil {
+			t.Errorf("NewPerlVersion(%q) returned error: %v",
+				test.version, err)
+			continue
+		}
+		if !reflect.DeepEqual(pv.version, test.components) {
+			t.Errorf("NewPerlVersion(%q) components => %v, expected %v",
+				test.version, pv.version, test.components)
+		}
+		if pv.Normal() != test.normal {
+			t.Errorf("NewPerlVersion(%q).Normal() => %q, expected %q",
+				test.version, pv.Normal(), test.normal)
+		}
+		if pv.IsQv() != test.qv {
+			t.Errorf("NewPerlVersion(%q).IsQv() => %t, expected %t",
+				test.version, pv.IsQv(), test.qv)
+		}
+	}
+}
+
+func TestNumericEqual(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		equal   bool
+		numeric bool
+	}{
+		// "1.2.3" is a dotted version just like "v1.2.3", so these
+		// are equal either way
+		{"1.2.3", "v1.2.3", true, true},
+		{"1.002003", "v1.2.3", true, true},
+		{"1.2", "v1.200.0", true, true},
+		{"v1.1000", "1.1", false, true},
+		{"v1.2.3", "1.2", false, false},
+		{"undef", "0.000", true, true},
+	}
+	for _, test := range tests {
+		a, b := MustParse(test.a), MustParse(test.b)
+		if actual := a.Equal(&b); actual != test.equal {
+			t.Errorf("NewPerlVersion(%q).Equal(%q) => %t, expected %t",
+				test.a, test.b, actual, test.equal)
+		}
+		if actual := a.NumericEqual(&b); actual != test.numeric {
+			t.Errorf("NewPerlVersion(%q).NumericEqual(%q) => %t, "+
+				"expected %t", test.a, test.b, actual, test.numeric)
+		}
+	}
+}
+
+func TestIsAmbiguous(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{"1.2", true},
+		{"1.02", true},
+		{"0.1_2", true},
+		{"12.345", false},
+		{".1", true},
+		{"1.002", false},
+		{"1.002003", true},
+		{"42", false},
+		{"1.", false},
+		{"1.2.3", false},
+		{"v1.2", false},
+		{"v1.2.3", false},
+		{"undef", false},
+		{"foo", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if actual := IsAmbiguous(test.version); actual != test.expected {
+			t.Errorf("IsAmbiguous(%q) => %t, expected %t", test.version,
+				actual, test.expected)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	pv := MustParse("v1.2.3")
+	alpha := MustParse("1.02_03")
+	tests := []struct {
+		format   string
+		value    interface{}
+		expected string
+	}{
+		{"%s", pv, "v1.2.3"},
+		{"%s", &pv, "v1.2.3"},
+		{"%8s|", pv, "  v1.2.3|"},
+		{"%-8s|", pv, "v1.2.3  |"},
+		{"%q", alpha, `"1.02_03"`},
+		{"%v", pv, "v1.2.3"},
+		{"%v", alpha, "v1.20.300"},
+		{"%v", &alpha, "v1.20.300"},
+		{"%+v", pv, "v1.2.3 (1.002003)"},
+		{"%+v", alpha, "v1.20.300 (1.020300)"},
+		{"%#v", pv, `perl_version.Version{original:"v1.2.3", ` +
+			`version:[]int64{1, 2, 3}, alpha:false, qv:true}`},
+		{"%#v", alpha, `perl_version.Version{original:"1.02_03", ` +
+			`version:[]int64{1, 20, 300}, alpha:true, qv:false}`},
+		{"%v", Version{}, "v0.0.0"},
+		{"%d", pv, "%!d(perl_version.Version=v1.2.3)"},
+	}
+	for _, test := range tests {
+		if actual := fmt.Sprintf(test.format, test.value); actual !=
+			test.expected {
+			t.Errorf("fmt.Sprintf(%q, %s) => %q, expected %q",
+				test.format, test.value, actual, test.expected)
+		}
+	}
+}
+
+func TestIsTrue(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{"undef", false},
+		{"0", false},
+		{"0.000", false},
+		{"v0", false},
+		{"v0.0.0", false},
+		{"v0.0.1", true},
+		{"0.001", true},
+		{"0.000_001", true},
+		{"1", true},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		if actual := pv.IsTrue(); actual != test.expected {
+			t.Errorf("NewPerlVersion(%q).IsTrue() => %t, expected %t",
+				test.version, actual, test.expected)
+		}
+	}
+	var zero Version
+	if zero.IsTrue() {
+		t.Errorf("Version{}.IsTrue() => true, expected false")
+	}
+}
+
+func TestParseOrUndef(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"v1.2.3", "v1.2.3"},
+		{"1.02", "1.02"},
+		{"undef", "undef"},
+		{"1.2_3_4", "undef"},
+		{"not a version", "undef"},
+		{"", "undef"},
+	}
+	for _, test := range tests {
+		pv := ParseOrUndef(test.version)
+		if pv.Raw() != test.expected {
+			t.Errorf("ParseOrUndef(%q) => %q, expected %q", test.version,
+				pv.Raw(), test.expected)
+		}
+		if test.expected == "undef" && !pv.IsUndef() {
+			t.Errorf("ParseOrUndef(%q).IsUndef() => false, expected true",
+				test.version)
+		}
+	}
+}
+
+func TestNormalTrimmed(t *testing.T) {
+	tests := []struct {
+		version string
+		normal  string
+		trimmed string
+	}{
+		{"v1", "v1.0.0", "v1"},
+		{"1.2", "v1.200.0", "v1.200"},
+		{".1", "v0.100.0", "v0.100"},
+		{"v1.2", "v1.2.0", "v1.2"},
+		{"v1.2_3", "v1.23.0", "v1.23"},
+		{"v1.2.0", "v1.2.0", "v1.2.0"},
+		{"1.2.3.4", "v1.2.3.4", "v1.2.3.4"},
+		{"42", "v42.0.0", "v42"},
+		{"undef", "v0.0.0", "v0"},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		if actual := pv.Normal(); actual != test.normal {
+			t.Errorf("NewPerlVersion(%q).Normal() => %q, expected %q",
+				test.version, actual, test.normal)
+		}
+		if actual := pv.NormalTrimmed(); actual != test.trimmed {
+			t.Errorf("NewPerlVersion(%q).NormalTrimmed() => %q, "+
+				"expected %q", test.version, actual, test.trimmed)
+		}
+	}
+	var zero Version
+	if actual := zero.NormalTrimmed(); actual != "v0" {
+		t.Errorf("Version{}.NormalTrimmed() => %q, expected %q", actual,
+			"v0")
+	}
+}
+
+func TestIdentical(t *testing.T) {
+	tests := []struct {
+		a, b      string
+		equal     bool
+		identical bool
+	}{
+		{"v1.2", "v1.2", true, true},
+		{"v1.2", "v1.2.0", true, false},
+		{"1.2", "1.200", true, false},
+		{"1.002", "v1.2", true, false},
+		{"v1.2.3_0", "v1.2.30", true, false},
+		{"v1.2.3", "v1.2.4", false, false},
+	}
+	for _, test := range tests {
+		a, b := MustParse(test.a), MustParse(test.b)
+		if actual := a.Equal(&b); actual != test.equal {
+			t.Errorf("NewPerlVersion(%q).Equal(%q) => %t, expected %t",
+				test.a, test.b, actual, test.equal)
+		}
+		if actual := a.Identical(&b); actual != test.identical {
+			t.Errorf("NewPerlVersion(%q).Identical(%q) => %t, "+
+				"expected %t", test.a, test.b, actual, test.identical)
+		}
+	}
+
+	// the same original with different components, as from a hand-edited
+	// cache entry
+	a := MustParse("v1.2")
+	b := a.Clone()
+	b.version = b.version[:2]
+	if !a.Equal(&b) || a.Identical(&b) {
+		t.Errorf("truncated clone of %q: Equal => %t, Identical => %t, "+
+			"expected true, false", "v1.2", a.Equal(&b), a.Identical(&b))
+	}
+	c := a.Clone()
+	if !a.Identical(&c) {
+		t.Errorf("NewPerlVersion(%q).Identical(Clone()) => false, "+
+			"expected true", "v1.2")
+	}
+}
+
+func TestDedup(t *testing.T) {
+	tests := []struct {
+		versions []string
+		expected []string
+	}{
+		{nil, []string{}},
+		{[]string{"v1.2.3"}, []string{"v1.2.3"}},
+		{[]string{"1.002", "v1.2", "v1.3", "v1.2.0", "1.2", "1.002000",
+			"1.003"}, []string{"1.002", "v1.3", "1.2"}},
+		{[]string{"undef", "0", "v0.0.0", "v1.2.3_0", "v1.2.30"},
+			[]string{"undef", "v1.2.3_0"}},
+	}
+	for _, test := range tests {
+		vs := make([]Version, len(test.versions))
+		for i, version := range test.versions {
+			vs[i] = MustParse(version)
+		}
+		deduped := Dedup(vs)
+		actual := make([]string, len(deduped))
+		for i, pv := range deduped {
+			actual[i] = pv.Raw()
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Dedup(%q) => %q, expected %q", test.versions,
+				actual, test.expected)
+		}
+	}
+
+	// a mirror-sized list, with every version spelled three ways
+	var vs []Version
+	for i := 0; i < 10000; i++ {
+		vs = append(vs, MustParse(fmt.Sprintf("v1.%d", i)),
+			MustParse(fmt.Sprintf("v1.%d.0", i)),
+			MustParse(fmt.Sprintf("1.%03d", i%1000)))
+	}
+	deduped := Dedup(vs)
+	if len(deduped) != 10000 {
+		t.Fatalf("Dedup() of %d versions => %d, expected 10000",
+			len(vs), len(deduped))
+	}
+	for i, pv := range deduped {
+		if expected := fmt.Sprintf("v1.%d", i); pv.Raw() != expected {
+			t.Errorf("Dedup()[%d] => %q, expected %q", i, pv.Raw(),
+				expected)
+			break
+		}
+	}
+}
+
+func TestVersion_Overlay(t *testing.T) {
+	tests := []struct {
+		base     string
+		partial  string
+		expected string
+	}{
+		{"v5.34.0", "v0.0.1", "v5.34.1"},
+		{"v5.34.7", "v0.0.1", "v5.34.1"},
+		{"v5.34.0", "v0.36.0", "v5.36.0"},
+		{"v5.34.0", "v6", "v6.34.0"},
+		{"v5.34", "v0.0.1", "v5.34.1"},
+		{"v1.2.3", "v0.0.0.4", "v1.2.3.4"},
+		{"v1.2.3.4", "v0.0.5", "v1.2.5.4"},
+		{"v1.2.3", "undef", "v1.2.3"},
+		{"5.034", "v0.0.1", "5.034001"},
+		{"5.034", "0.000001", "5.034001"},
+		{"5.034", "v0.0.1000", "v5.34.1000"},
+		{"v1.2.3_4", "v0.0.0.1", "v1.2.34.1"},
+	}
+	for _, test := range tests {
+		base, partial := MustParse(test.base), MustParse(test.partial)
+		actual := base.Overlay(&partial)
+		if actual.Raw() != test.expected {
+			t.Errorf("NewPerlVersion(%q).Overlay(%q) => %q, expected "+
+				"%q", test.base, test.partial, actual.Raw(),
+				test.expected)
+		}
+		expected := MustParse(test.expected)
+		if !actual.Identical(&expected) {
+			t.Errorf("NewPerlVersion(%q).Overlay(%q) => %#v, expected "+
+				"%#v", test.base, test.partial, actual, expected)
+		}
+		if again := MustParse(test.base); !again.Identical(&base) {
+			t.Errorf("NewPerlVersion(%q).Overlay(%q) changed the base",
+				test.base, test.partial)
+		}
+	}
+}
+
+func TestClamp(t *testing.T) {
+	low, high := MustParse("v1.2.0"), MustParse("v2.0.0")
+	tests := []struct {
+		version   string
+		low, high *Version
+		expected  string
+	}{
+		{"v1.0.0", &low, &high, "v1.2.0"},
+		{"v1.2.0", &low, &high, "v1.2.0"},
+		{"1.5", &low, &high, "1.5"},
+		{"v2.0.0", &low, &high, "v2.0.0"},
+		{"v2.0.1", &low, &high, "v2.0.0"},
+		{"v1.0.0", nil, &high, "v1.0.0"},
+		{"v3", nil, &high, "v2.0.0"},
+		{"v1.0.0", &low, nil, "v1.2.0"},
+		{"v3", &low, nil, "v3"},
+		{"undef", nil, nil, "undef"},
+		// an inverted range clamps to low
+		{"1.5", &high, &low, "v2.0.0"},
+		{"v1.0.0", &high, &low, "v2.0.0"},
+		{"v3", &high, &low, "v2.0.0"},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		actual := pv.Clamp(test.low, test.high)
+		if actual.Raw() != test.expected {
+			t.Errorf("NewPerlVersion(%q).Clamp(%s, %s) => %q, "+
+				"expected %q", test.version, test.low, test.high,
+				actual.Raw(), test.expected)
+		}
+	}
+}
+
+func TestDottedAlphaMerge(t *testing.T) {
+	// the underscore is ignored, so the alpha digits are appended to the
+	// last component; the expected values are from Perl's version->parse
+	tests := []struct {
+		version    string
+		components []int64
+		normal     string
+	}{
+		{"v1.2.3_0", []int64{1, 2, 30}, "v1.2.30"},
+		{"v1.2.3_4", []int64{1, 2, 34}, "v1.2.34"},
+		{"v1.2.3_45", []int64{1, 2, 345}, "v1.2.345"},
+		{"v1.2.3_456", []int64{1, 2, 3456}, "v1.2.3456"},
+		{"v1.2.3_045", []int64{1, 2, 3045}, "v1.2.3045"},
+		{"v1.2.3.4_5", []int64{1, 2, 3, 45}, "v1.2.3.45"},
+		{"v1.2_34", []int64{1, 234, 0}, "v1.234.0"},
+		{"1.2.3_45", []int64{1, 2, 345}, "v1.2.345"},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		if !pv.IsAlpha() {
+			t.Errorf("NewPerlVersion(%q).IsAlpha() => false, expected "+
+				"true", test.version)
+		}
+		if !reflect.DeepEqual(pv.version, test.components) {
+			t.Errorf("NewPerlVersion(%q) components => %v, expected %v",
+				test.version, pv.version, test.components)
+		}
+		if pv.Normal() != test.normal {
+			t.Errorf("NewPerlVersion(%q).Normal() => %q, expected %q",
+				test.version, pv.Normal(), test.normal)
+		}
+	}
+}
+
+// TestPerlReference checks against testdata/perl_reference.json, which holds
+// the output of Perl's version.pm for each input. To extend it, append rows
+// generated from an actual perl, e.g. with
+//
+//	perl -MJSON::PP -Mversion -e '$v = version->parse($ARGV[0]);
+//	    print encode_json({input => $ARGV[0], normal => $v->normal,
+//	    numify => "" . $v->numify, is_alpha => $v->is_alpha ? \1 : \0,
+//	    is_qv => $v->is_qv ? \1 : \0})' 1.2.3
+func TestRoundTrip(t *testing.T) {
+	data, err := os.ReadFile("testdata/perl_reference.json")
+	if err != nil {
+		t.Fatalf("reading the reference data returned error: %v", err)
+	}
+	var rows []struct {
+		Input string `json:"input"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		t.Fatalf("decoding the reference data returned error: %v", err)
+	}
+	inputs := append([]string{"0 but true", "0E0"}, parseBoundaryInputs...)
+	for _, row := range rows {
+		inputs = append(inputs, row.Input)
+	}
+
+	mustRoundTrip := func(what, version string, pv Version) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("NewPerlVersion(%q).%s: %v", version, what,
+					r)
+			}
+		}()
+		pv.MustRoundTrip()
+	}
+	for _, version := range inputs {
+		pv, err := Parse(version)
+		if err != nil {
+			continue
+		}
+		reparsed, err := Parse(pv.Raw())
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", pv.Raw(), err)
+			continue
+		}
+		if !reflect.DeepEqual(reparsed, pv) {
+			t.Errorf("Parse(%q) => %#v, expected %#v", pv.Raw(),
+				reparsed, pv)
+		}
+
+		mustRoundTrip("MustRoundTrip()", version, pv)
+		mustRoundTrip("Canonical()", version, pv.Canonical())
+		mustRoundTrip("StableRelease()", version, pv.StableRelease())
+		mustRoundTrip("BumpAlpha()", version, pv.BumpAlpha())
+		mustRoundTrip("Clone()", version, pv.Clone())
+		mustRoundTrip("Trimmed()", version, pv.Trimmed())
+		if declared, err := Declare(version); err == nil {
+			mustRoundTrip("Declare()", version, declared)
+		}
+		c, err := ParseConstraint("~> " + version)
+		if err == nil && c.Op == OpCompatible {
+			mustRoundTrip("compatibleCeiling()", version,
+				c.compatibleCeiling())
+		}
+	}
+
+}
+
+func TestPerlReference(t *testing.T) {
+	data, err := os.ReadFile("testdata/perl_reference.json")
+	if err != nil {
+		t.Fatalf("reading the reference data returned error: %v", err)
+	}
+	var rows []struct {
+		Input   string `json:"input"`
+		Normal  string `json:"normal"`
+		Numify  string `json:"numify"`
+		IsAlpha bool   `json:"is_alpha"`
+		IsQv    bool   `json:"is_qv"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		t.Fatalf("decoding the reference data returned error: %v", err)
+	}
+	for _, row := range rows {
+		pv, err := Parse(row.Input)
+		if err != nil {
+			t.Errorf("NewPerlVersion(%q) returned error: %v", row.Input,
+				err)
+			continue
+		}
+		if pv.Normal() != row.Normal {
+			t.Errorf("NewPerlVersion(%q).Normal() => %q, expected %q",
+				row.Input, pv.Normal(), row.Normal)
+		}
+		if pv.NumifyString() != row.Numify {
+			t.Errorf("NewPerlVersion(%q).NumifyString() => %q, "+
+				"expected %q", row.Input, pv.NumifyString(), row.Numify)
+		}
+		if pv.IsAlpha() != row.IsAlpha {
+			t.Errorf("NewPerlVersion(%q).IsAlpha() => %t, expected %t",
+				row.Input, pv.IsAlpha(), row.IsAlpha)
+		}
+		if pv.IsQv() != row.IsQv {
+			t.Errorf("NewPerlVersion(%q).IsQv() => %t, expected %t",
+				row.Input, pv.IsQv(), row.IsQv)
+		}
+	}
+}
+
+func TestVersion_ImpliedComponents(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected int
+	}{
+		{".1.2", 1},
+		{".1.2.3", 1},
+		{"v1", 2},
+		{"v1.2", 1},
+		{"v1.2_3", 1},
+		{"v1.2.3", 0},
+		{"v1.2.3.4", 0},
+		{"1.2.3", 0},
+		{"1.2.3_4", 0},
+		{".1", 1},
+		{"1.", 1},
+		{"1", 0},
+		{"1.002", 0},
+		{"1.02_03", 0},
+		{"undef", 0},
+		{"0 but true", 0},
+	}
+	for _, test := range tests {
+		pv, _ := Parse(test.version, WithZeroButTrue())
+		actual := pv.ImpliedComponents()
+		if actual != test.expected {
+			t.Errorf("NewPerlVersion(%q).ImpliedComponents() => %d, "+
+				"expected %d", test.version, actual, test.expected)
+		}
+	}
+
+	var zero Version
+	if actual := zero.ImpliedComponents(); actual != 0 {
+		t.Errorf("Version{}.ImpliedComponents() => %d, expected 0",
+			actual)
+	}
+}
+
+func TestStableRelease(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"v1.2.3_0", "v1.2.30"},
+		{"v1.2.3_45", "v1.2.345"},
+		{"v1.2_3", "v1.23"},
+		{"1.2.3_4", "v1.2.34"},
+		{"1.2345_01", "1.234501"},
+		{"1.02_03", "1.020300"},
+		{"0.000_001", "0.000001"},
+		{"v1.2.3", "v1.2.3"},
+		{"1.02", "1.02"},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		stable := pv.StableRelease()
+		if stable.Raw() != test.expected {
+			t.Errorf("NewPerlVersion(%q).StableRelease() => %q, "+
+				"expected %q", test.version, stable.Raw(), test.expected)
+		}
+		// it should be exactly what parsing its original gives
+		expected := MustParse(test.expected)
+		if !stable.Identical(&expected) {
+			t.Errorf("NewPerlVersion(%q).StableRelease() => %#v, "+
+				"expected %#v", test.version, stable, expected)
+		}
+		if !stable.Equal(&pv) {
+			t.Errorf("NewPerlVersion(%q).StableRelease() isn't Equal to "+
+				"the alpha version", test.version)
+		}
+	}
+}
+
+func TestBumpAlpha(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"v1.2.3_1", "v1.2.3_2"},
+		{"v1.2.3_9", "v1.2.3_10"},
+		{"v1.2_3", "v1.2_4"},
+		{"1.002_09", "1.002_10"},
+		{"1.002_9", "1.002_91"},
+		{"1.002_99", "1.002_991"},
+		{"v1.2.3", "v1.2.3_1"},
+		{"1.002", "1.002_1"},
+		{"v1", "v1.0.0_1"},
+		{"1", "1.000_1"},
+		{"undef", "0.000_1"},
+		{"", "0.000_1"},
+	}
+	for _, test := range tests {
+		var pv Version
+		if test.version != "" {
+			pv = MustParse(test.version)
+		}
+		bumped := pv.BumpAlpha()
+		if bumped.Raw() != test.expected {
+			t.Errorf("NewPerlVersion(%q).BumpAlpha() => %q, "+
+				"expected %q", test.version, bumped.Raw(), test.expected)
+		}
+		expected := MustParse(test.expected)
+		if !bumped.Identical(&expected) {
+			t.Errorf("NewPerlVersion(%q).BumpAlpha() => %#v, "+
+				"expected %#v", test.version, bumped, expected)
+		}
+		if !bumped.IsAlpha() {
+			t.Errorf("NewPerlVersion(%q).BumpAlpha().IsAlpha() => "+
+				"false, expected true", test.version)
+		}
+		if !pv.LessThan(&bumped) {
+			t.Errorf("NewPerlVersion(%q).BumpAlpha() => %q, which "+
+				"isn't newer", test.version, bumped.Raw())
+		}
+	}
+}
+
+func TestMarshalJSONAs(t *testing.T) {
+	tests := []struct {
+		version  string
+		form     Form
+		expected string
+	}{
+		{"1.2", FormStringify, `"1.2"`},
+		{"1.2", FormOriginal, `"1.2"`},
+		{"1.2", FormNormal, `"v1.200.0"`},
+		{"undef", FormStringify, `"0"`},
+		{"undef", FormOriginal, `"undef"`},
+		{"undef", FormNormal, `"v0.0.0"`},
+		{"v1.2.3_4", FormNormal, `"v1.2.34"`},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		data, err := pv.MarshalJSONAs(test.form)
+		if err != nil {
+			t.Errorf("NewPerlVersion(%q).MarshalJSONAs(%d) returned "+
+				"error: %v", test.version, test.form, err)
+			continue
+		}
+		if string(data) != test.expected {
+			t.Errorf("NewPerlVersion(%q).MarshalJSONAs(%d) => %s, "+
+				"expected %s", test.version, test.form, data,
+				test.expected)
+		}
+	}
+	pv := MustParse("1.2")
+	if _, err := pv.MarshalJSONAs(Form(-1)); err == nil {
+		t.Errorf("MarshalJSONAs(-1) => nil error, expected error")
+	}
+
+	defer func() { JSONForm = FormStringify }()
+	JSONForm = FormNormal
+	data, err := json.Marshal(struct{ V *Version }{&pv})
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+	if string(data) != `{"V":"v1.200.0"}` {
+		t.Errorf("json.Marshal() with JSONForm = FormNormal => %s, "+
+			"expected %s", data, `{"V":"v1.200.0"}`)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int64
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.2", "v1.2.0", 0},
+		{"v1.2.3", "v1.2.4", 1},
+		{"v1.2.4", "v1.2.3", 1},
+		{"v1.2.3", "v1.2.3.7", 7},
+		{"v1.2.3", "v1.4.0", 2000000},
+		{"v1.2.3", "v2.0.0", 1000000000000},
+		{"v1.0.0", "v1.2000000.0", 999999000000},
+		{"1.2", "1.3", 100000000},
+		{"v0.0.0", "v9223372036854775807.0.0",
+			999999000000000000},
+	}
+	for _, test := range tests {
+		a, b := MustParse(test.a), MustParse(test.b)
+		if actual := a.Distance(&b); actual != test.expected {
+			t.Errorf("NewPerlVersion(%q).Distance(%q) => %d, expected %d",
+				test.a, test.b, actual, test.expected)
+		}
+	}
+
+	// any major bump outweighs any minor one, which outweighs any patch
+	base := MustParse("v1.0.0")
+	major := MustParse("v2.0.0")
+	minor := MustParse("v1.999999999.999999999")
+	patch := MustParse("v1.0.999999999")
+	if base.Distance(&major) <= base.Distance(&minor) {
+		t.Errorf("major distance %d <= minor distance %d",
+			base.Distance(&major), base.Distance(&minor))
+	}
+	if base.Distance(&minor) <= base.Distance(&patch) {
+		t.Errorf("minor distance %d <= patch distance %d",
+			base.Distance(&minor), base.Distance(&patch))
+	}
+}
+
+func TestZeroButTrue(t *testing.T) {
+	for _, version := range []string{"0 but true", "0E0", "0e0"} {
+		// like version.pm, it's non-numeric data without the option
+		if _, err := Parse(version); err == nil {
+			t.Errorf("NewPerlVersion(%q) => nil error, expected error",
+				version)
+		}
+		pv, err := Parse(version, WithZeroButTrue())
+		if err != nil {
+			t.Errorf("Parse(%q, WithZeroButTrue()) returned error: %v",
+				version, err)
+			continue
+		}
+		zero := MustParse("0")
+		if !pv.Equal(&zero) || pv.Numify() != 0 {
+			t.Errorf("Parse(%q, WithZeroButTrue()) => %v, expected 0",
+				version, pv)
+		}
+		if !pv.IsTrue() {
+			t.Errorf("Parse(%q, WithZeroButTrue()).IsTrue() => false, "+
+				"expected true", version)
+		}
+		if pv.IsUndef() || pv.IsQv() || pv.IsAlpha() {
+			t.Errorf("Parse(%q, WithZeroButTrue()) has unexpected "+
+				"flags: %#v", version, pv)
+		}
+		if err := pv.Validate(); err != nil {
+			t.Errorf("Parse(%q, WithZeroButTrue()).Validate() => %v, "+
+				"expected nil", version, err)
+		}
+		// neither grammar has them
+		for _, grammar := range []Option{WithStrictOnly(),
+			WithLaxOnly()} {
+			_, err := Parse(version, WithZeroButTrue(), grammar)
+			if err == nil {
+				t.Errorf("Parse(%q) with a grammar option => nil "+
+					"error, expected error", version)
+			}
+		}
+	}
+	for _, version := range []string{"0 but false", "1E0", "0 BUT TRUE"} {
+		if _, err := Parse(version, WithZeroButTrue()); err == nil {
+			t.Errorf("Parse(%q, WithZeroButTrue()) => nil error, "+
+				"expected error", version)
+		}
+	}
+}
+
+func TestSpaceship(t *testing.T) {
+	data, err := os.ReadFile("testdata/perl_reference.json")
+	if err != nil {
+		t.Fatalf("reading the reference data returned error: %v", err)
+	}
+	var rows []struct {
+		Input string `json:"input"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		t.Fatalf("decoding the reference data returned error: %v", err)
+	}
+	versions := []Version{MustParse("1.02_03"), MustParse("1.020300")}
+	for _, row := range rows {
+		versions = append(versions, MustParse(row.Input))
+	}
+	for i := range versions {
+		for j := range versions {
+			a, b := &versions[i], &versions[j]
+			actual := Spaceship(a, b)
+			if expected := a.Compare(b); actual != expected {
+				t.Errorf("Spaceship(%q, %q) => %d, but Compare "+
+					"=> %d", a.Raw(), b.Raw(), actual, expected)
+			}
+			if actual != -Spaceship(b, a) {
+				t.Errorf("Spaceship(%q, %q) isn't antisymmetric",
+					a.Raw(), b.Raw())
+			}
+		}
+	}
+
+	// like Perl, the alpha flag doesn't break the tie
+	a, b := MustParse("1.02_03"), MustParse("1.020300")
+	if actual := Spaceship(&a, &b); actual != 0 {
+		t.Errorf("Spaceship(%q, %q) => %d, expected 0", "1.02_03",
+			"1.020300", actual)
+	}
+	undef, one := Undef(), MustParse("1")
+	tests := []struct {
+		a, b     *Version
+		expected int
+	}{
+		{nil, nil, 0},
+		{nil, &undef, 0},
+		{&undef, nil, 0},
+		{nil, &one, -1},
+		{&one, nil, 1},
+	}
+	for _, test := range tests {
+		if actual := Spaceship(test.a, test.b); actual != test.expected {
+			t.Errorf("Spaceship(%q, %q) => %d, expected %d",
+				test.a.String(), test.b.String(), actual,
+				test.expected)
+		}
+	}
+}
+
+func TestCompareOrdering(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected Ordering
+	}{
+		{"v1.2.3", "v1.2.4", Less},
+		{"v1.2.3", "1.002003", Equal},
+		{"v1.2.4", "v1.2.3", Greater},
+		{"v1.2.3_0", "v1.2.30", Equal},
+		{"v1.2.30", "v1.2.3_0", Equal},
+		{"undef", "0", Equal},
+	}
+	for _, test := range tests {
+		a, b := MustParse(test.a), MustParse(test.b)
+		actual := a.CompareOrdering(&b)
+		if actual != test.expected {
+			t.Errorf("NewPerlVersion(%q).CompareOrdering(%q) => %v, "+
+				"expected %v", test.a, test.b, actual, test.expected)
+		}
+		if int(actual) != a.Compare(&b) {
+			t.Errorf("NewPerlVersion(%q).CompareOrdering(%q) => %d, "+
+				"but Compare => %d", test.a, test.b, actual,
+				a.Compare(&b))
+		}
+	}
+	if Ordering(2).String() != "Ordering(2)" {
+		t.Errorf("Ordering(2).String() => %q, expected %q",
+			Ordering(2).String(), "Ordering(2)")
+	}
+}
+
+func TestInSeries(t *testing.T) {
+	tests := []struct {
+		version  string
+		series   string
+		expected bool
+	}{
+		{"v5.34.2", "v5.34", true},
+		{"v5.34.0", "v5.34", true},
+		{"v5.36.0", "v5.34", false},
+		{"v5.34", "v5.34.2", false},
+		{"v5.34.2", "v5.34.2", true},
+		{"v5.34.2", "v5.34.3", false},
+		{"v5.34.2", "v5.34.0", false},
+		{"v5.34.2.1", "5.34.2", true},
+		{"v5.34.2", "5.034", true},
+		{"v5.34.2", "v5", true},
+		{"v6.0.0", "v5", false},
+	}
+	for _, test := range tests {
+		v, series := MustParse(test.version), MustParse(test.series)
+		if actual := v.InSeries(&series); actual != test.expected {
+			t.Errorf("NewPerlVersion(%q).InSeries(%q) => %t, expected %t",
+				test.version, test.series, actual, test.expected)
+		}
+	}
+}
+
+func TestNumifyMatchesNumifyString(t *testing.T) {
+	versions := []string{"v1.2.3.4.5.6.7", "v1.1000.3", "1.0000000000000000001",
+		"v9007199254740993.1", "v1.2.3.4.5.6.7.8.9.10", "0.000001"}
+	for _, test := range isQvTests {
+		versions = append(versions, test.version)
+	}
+	for _, version := range versions {
+		pv := MustParse(version)
+		expected, _ := strconv.ParseFloat(pv.NumifyString(), 64)
+		if actual := pv.Numify(); actual != expected {
+			t.Errorf("NewPerlVersion(%q).Numify() => %v, expected %v",
+				version, actual, expected)
+		}
+	}
+
+	pv := MustParse("v1.2.3.4.5")
+	allocs := testing.AllocsPerRun(100, func() { _ = pv.Numify() })
+	if allocs != 0 {
+		t.Errorf("NewPerlVersion(%q).Numify() made %v allocations, "+
+			"expected 0", "v1.2.3.4.5", allocs)
+	}
+}
+
+func BenchmarkNumify(b *testing.B) {
+	pv := MustParse("v1.2.3.4.5")
+	for i := 0; i < b.N; i++ {
+		_ = pv.Numify()
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package perl_version
+
+// Conversions between Perl versions and semantic versions (https://semver.org),
+// for interoperating with tooling that only understands the latter.
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// semVerRegexp is the regex suggested by the SemVer 2.0.0 spec, with an
+// optional leading "v".
+var semVerRegexp = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.` +
+	`(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)` +
+	`(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// ToSemVer converts the version to a best-effort SemVer 2.0.0 string. The
+// first three components become major.minor.patch, with missing ones being
+// zero, so "v1.2" is "1.2.0". Decimal versions go through their components as
+// well, meaning "1.02" is "1.20.0". The conversion is lossy in a few ways:
+//
+//   - alpha versions get an "-alpha" prerelease tag, but the alpha part isn't
+//     kept separately from the components it was merged into
+//   - components after the third are kept only as dot-separated build
+//     metadata, e.g. "v1.2.3.4" is "1.2.3+4", which SemVer ignores when
+//     comparing
+//   - whether the version was qv or decimal isn't kept
+//
+// SemVer has no leading "v", so prepend one for golang.org/x/mod/semver.
+func (v *Version) ToSemVer() (string, error) {
+	var parts [3]string
+	for i := range parts {
+		component := v.component(i)
+		if component < 0 {
+			return "", errors.New("invalid version: negative " +
+				"component in " + v.original)
+		}
+		parts[i] = strconv.FormatInt(component, 10)
+	}
+	semver := strings.Join(parts[:], ".")
+	if v.alpha {
+		semver += "-alpha"
+	}
+	if len(v.version) > 3 {
+		build := make([]string, len(v.version)-3)
+		for i, component := range v.version[3:] {
+			if component < 0 {
+				return "", errors.New("invalid version: " +
+					"negative component in " + v.original)
+			}
+			build[i] = strconv.FormatInt(component, 10)
+		}
+		semver += "+" + strings.Join(build, ".")
+	}
+	return semver, nil
+}
+
+// FromSemVer converts a SemVer 2.0.0 string, optionally with a leading "v",
+// into a qv version with three components. Any prerelease makes it an alpha
+// version; since Perl's alpha part is only an underscore within the
+// components, the original is spelled with the patch after the underscore,
+// e.g. "1.2.3-alpha" becomes "v1.2.0_3", which Perl parses to {1, 2, 3}. The
+// prerelease identifiers themselves are dropped, so "-rc.1" and "-alpha" give
+// the same version, as is all build metadata.
+func FromSemVer(semver string) (Version, error) {
+	matches := semVerRegexp.FindStringSubmatch(semver)
+	if matches == nil {
+		return Version{}, errors.New("invalid semantic version: " +
+			semver)
+	}
+	original := "v" + matches[1] + "." + matches[2] + "." + matches[3]
+	if matches[4] != "" {
+		original = "v" + matches[1] + "." + matches[2] + ".0_" +
+			matches[3]
+	}
+	return Parse(original)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+package perl_version
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVersion_ToSemVer(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"v1.2.3", "1.2.3"},
+		{"v1.2", "1.2.0"},
+		{"v1", "1.0.0"},
+		{"v0.0.0", "0.0.0"},
+		{"undef", "0.0.0"},
+		{"1.2.3", "1.2.3"},
+		{"1.02", "1.20.0"},
+		{"1.002003", "1.2.3"},
+		{"42", "42.0.0"},
+		{"v1.2.3.4", "1.2.3+4"},
+		{"v1.2.3.4.5", "1.2.3+4.5"},
+		{"v1.2.3_4", "1.2.34-alpha"},
+		{"1.02_03", "1.20.300-alpha"},
+		{"v1.2.3.4_5", "1.2.3-alpha+45"},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		actual, err := pv.ToSemVer()
+		if err != nil {
+			t.Errorf("NewPerlVersion(%q).ToSemVer() returned error: "+
+				"%v", test.version, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("NewPerlVersion(%q).ToSemVer() => %q, expected "+
+				"%q", test.version, actual, test.expected)
+		}
+	}
+
+	negative := Version{original: "v1.-2.3", qv: true,
+		version: []int64{1, -2, 3}}
+	if _, err := negative.ToSemVer(); err == nil {
+		t.Errorf("ToSemVer() with a negative component => nil error, " +
+			"expected error")
+	}
+}
+
+func TestFromSemVer(t *testing.T) {
+	tests := []struct {
+		semver   string
+		expected []int64
+		alpha    bool
+	}{
+		{"1.2.3", []int64{1, 2, 3}, false},
+		{"v1.2.3", []int64{1, 2, 3}, false},
+		{"0.0.0", []int64{0, 0, 0}, false},
+		{"10.20.30", []int64{10, 20, 30}, false},
+		{"1.2.3-alpha", []int64{1, 2, 3}, true},
+		{"1.2.34-alpha", []int64{1, 2, 34}, true},
+		{"1.2.3-rc.1", []int64{1, 2, 3}, true},
+		{"1.2.3+build.5", []int64{1, 2, 3}, false},
+		{"v1.2.3-beta+exp.sha.5114f85", []int64{1, 2, 3}, true},
+	}
+	for _, test := range tests {
+		pv, err := FromSemVer(test.semver)
+		if err != nil {
+			t.Errorf("FromSemVer(%q) returned error: %v",
+				test.semver, err)
+			continue
+		}
+		if !reflect.DeepEqual(pv.version, test.expected) ||
+			pv.IsAlpha() != test.alpha || !pv.IsQv() {
+			t.Errorf("FromSemVer(%q) => %+v, expected components "+
+				"%v and alpha %t", test.semver, pv,
+				test.expected, test.alpha)
+		}
+		if err := pv.Validate(); err != nil {
+			t.Errorf("FromSemVer(%q).Validate() returned error: %v",
+				test.semver, err)
+		}
+	}
+
+	for _, semver := range []string{"", "1", "1.2", "1.2.3.4", "01.2.3",
+		"1.2.3-", "1.2.3+", "V1.2.3", "1.2.3-01", "foo"} {
+		if _, err := FromSemVer(semver); err == nil {
+			t.Errorf("FromSemVer(%q) => nil error, expected error",
+				semver)
+		}
+	}
+}
+
+func TestSemVerRoundTrip(t *testing.T) {
+	for _, semver := range []string{"0.0.0", "1.2.3", "1.20.300",
+		"1.2.3-alpha", "1.2.34-alpha", "2147483647.0.1"} {
+		pv, err := FromSemVer(semver)
+		if err != nil {
+			t.Fatalf("FromSemVer(%q) returned error: %v", semver,
+				err)
+		}
+		actual, err := pv.ToSemVer()
+		if err != nil {
+			t.Fatalf("ToSemVer() returned error: %v", err)
+		}
+		if actual != semver {
+			t.Errorf("FromSemVer(%q).ToSemVer() => %q", semver,
+				actual)
+		}
+	}
+
+	for _, version := range []string{"v1.2.3", "v1.2.3_4", "1.02", "v1"} {
+		pv := MustParse(version)
+		semver, err := pv.ToSemVer()
+		if err != nil {
+			t.Fatalf("NewPerlVersion(%q).ToSemVer() returned error: "+
+				"%v", version, err)
+		}
+		actual, err := FromSemVer(semver)
+		if err != nil {
+			t.Fatalf("FromSemVer(%q) returned error: %v", semver,
+				err)
+		}
+		if actual.Compare(&pv) != 0 {
+			t.Errorf("FromSemVer(%q) => %+v, expected a version "+
+				"equal to %+v", semver, actual, pv)
+		}
+	}
+}
@@ -20,12 +20,21 @@ import (
 )
 
 var (
-	strictRegexp = regexp.MustCompile(StrictVersionRegex)
+	strictRegexp       = regexp.MustCompile(StrictVersionRegex)
+	strictDottedRegexp = regexp.MustCompile(strictDottedFormR + `$`)
 )
 
+// StrictRegexp returns the compiled StrictVersionRegex, with leftmost-longest
+// matching already applied, as used by Parse. As with LaxRegexp, it's shared and
+// only anchored at the end.
+func StrictRegexp() *regexp.Regexp {
+	return strictRegexp
+}
+
 type strictDecimalForm struct {
 	integerPart  string
 	fractionPart string
+	width        int // digits per fraction component
 }
 
 type strictDottedForm struct {
@@ -41,54 +50,66 @@ type strict struct {
 	dottedMatches  strictDottedForm
 }
 
-func (d strictDecimalForm) toPerlVersion(original string) Version {
+func (d strictDecimalForm) toPerlVersion(original string) (Version, error) {
 	pv := Version{
 		original: original,
 		alpha:    false,
 		qv:       false,
 	}
 	trimmed := strings.TrimPrefix(d.fractionPart, ".")
-	fracValues := getFractionValue(trimmed)
+	fracValues, err := getFractionValue(trimmed, d.width)
+	if err != nil {
+		return Version{}, err
+	}
+	integer, err := parseInt64(d.integerPart)
+	if err != nil {
+		return Version{}, err
+	}
 	pv.version = make([]int64, len(fracValues)+1)
-	pv.version[0] = mustParseInt64(d.integerPart)
+	pv.version[0] = integer
 	copy(pv.version[1:], fracValues)
-	return pv
+	return pv, nil
 }
 
-func (d strictDottedForm) toPerlVersion(original string) Version {
+func (d strictDottedForm) toPerlVersion(original string) (Version, error) {
 	pv := Version{
 		original: original,
 		alpha:    false,
 		qv:       true,
 	}
-	trimmed := strings.TrimPrefix(d.dottedGroup, ".")
-	minors := strings.Split(trimmed, ".")
-	pv.version = make([]int64, len(minors)+1)
-	pv.version[0] = mustParseInt64(d.integerPart)
-	for i, part := range minors {
-		pv.version[i+1] = mustParseInt64(part)
+	minors, err := dottedToMinors(d.dottedGroup)
+	if err != nil {
+		return Version{}, err
+	}
+	integer, err := parseInt64(d.integerPart)
+	if err != nil {
+		return Version{}, err
 	}
-	return pv
+	pv.version = make([]int64, len(minors)+1)
+	pv.version[0] = integer
+	copy(pv.version[1:], minors)
+	return pv, nil
 }
 
-func (d strict) toPerlVersion() Version {
+func (d strict) toPerlVersion() (Version, error) {
 	if d.decimal != "" {
 		return d.decimalMatches.toPerlVersion(d.original)
 	} else if d.dotted != "" {
 		return d.dottedMatches.toPerlVersion(
 			d.original)
 	} else {
-		panic("logic error: strictRegexp matched but no version found")
+		return Version{}, errUnrecognizedForm
 	}
 }
 
-func strictVersion(matches []string) Version {
+func strictVersion(matches []string, width int) (Version, error) {
 	return strict{
 		original: matches[0],
 		decimal:  matches[1],
 		decimalMatches: strictDecimalForm{
 			integerPart:  matches[2],
 			fractionPart: matches[3],
+			width:        width,
 		},
 		dotted: matches[4],
 		dottedMatches: strictDottedForm{
@@ -15,38 +15,459 @@
 package perl_version
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 // Here are functions for working with strings as perl versions. Generally just
 // wrappers around the respective methods on the Version type, so if you're
 // comparing versions repeatedly, you should use the Version type directly.
 
+// ErrEmptyVersion is returned by Parse when it's given an empty string, unless
+// WithEmptyAsUndef is set.
+var ErrEmptyVersion = errors.New("invalid version string: empty version")
+
 // Parse parses a string into a Version. The string can be either a lax or
-// strict versioning scheme, as defined in version::Internals.
-func Parse(version string) (Version, error) {
-	laxMatch := laxRegexp.FindStringSubmatch(version)
-	strictMatch := strictRegexp.FindStringSubmatch(version)
+// strict versioning scheme, as defined in version::Internals. Any options can
+// be given to deviate from that; see Option.
+func Parse(version string, opts ...Option) (Version, error) {
+	pv, _, err := parse(version, newParseOptions(opts))
+	return pv, err
+}
+
+// ParseBytes is Parse for a byte slice, for reading versions straight out of a
+// buffer, and gives exactly the same result as Parse(string(b)). The grammars
+// are matched against b first, and it's only copied once it's accepted, so
+// scanning through input that's mostly not versions saves an allocation per
+// rejection. The Version keeps its original as a string, so a valid b is
+// copied once, and b isn't retained.
+func ParseBytes(b []byte, opts ...Option) (Version, error) {
+	o := newParseOptions(opts)
+	if err := rejectBytes(b, o); err != nil {
+		return Version{}, err
+	}
+	pv, _, err := parse(string(b), o)
+	return pv, err
+}
+
+// rejectBytes returns the error parse would give for b, if it can tell from b
+// alone that none of the grammars match. A nil error only means b has to be
+// parsed properly.
+func rejectBytes(b []byte, o *parseOptions) error {
+	if o.trimSpace {
+		b = bytes.TrimSpace(b)
+	}
+	// these can rescue a string the grammars don't match, or reject it with
+	// a more specific error, so they're left to parse
+	if len(b) == 0 || o.grammar == strictGrammar || o.uppercaseV ||
+		o.scientific || o.zeroButTrue || o.fractionWidth < 1 ||
+		o.fractionWidth > maxFractionWidth ||
+		string(bytes.TrimRight(b, ".")) == "v" {
+		return nil
+	}
+	if matchesFullyBytes(laxRegexp, b) || (o.grammar == anyGrammar &&
+		matchesFullyBytes(strictRegexp, b)) {
+		return nil
+	}
+	if o.grammar == laxGrammar {
+		return errors.New("invalid lax version string: " + string(b))
+	}
+	return errors.New("invalid version string: " + string(b))
+}
+
+// Kind is which of the grammars matched a version string, as reported by
+// ParseWithKind.
+type Kind int
+
+const (
+	// UndefKind is the literal "undef". It isn't named Undef, since that's
+	// taken by the function.
+	UndefKind Kind = iota
+	// StrictDecimal is a strict decimal version, e.g. "1.002".
+	StrictDecimal
+	// StrictDotted is a strict dotted-decimal version, e.g. "v1.2.3".
+	StrictDotted
+	// LaxDecimal is a decimal version only the lax grammar accepts, e.g.
+	// "1.002_03" or ".1".
+	LaxDecimal
+	// LaxDotted is a dotted-decimal version only the lax grammar accepts,
+	// e.g. "v1.2" or "1.2.3".
+	LaxDotted
+	// ZeroButTrueKind is "0 but true" or "0E0", with WithZeroButTrue.
+	ZeroButTrueKind
+)
+
+// String returns the kind's name, e.g. "StrictDecimal".
+func (k Kind) String() string {
+	switch k {
+	case UndefKind:
+		return "Undef"
+	case StrictDecimal:
+		return "StrictDecimal"
+	case StrictDotted:
+		return "StrictDotted"
+	case LaxDecimal:
+		return "LaxDecimal"
+	case LaxDotted:
+		return "LaxDotted"
+	case ZeroButTrueKind:
+		return "ZeroButTrue"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// ParseWithKind is Parse, but also reports which grammar the string matched.
+// Where both grammars match, Parse prefers the strict one unless the lax match
+// is longer, and the kind reflects that choice, so "1.2" is StrictDecimal
+// while "1.2_3" is LaxDecimal; with WithLaxOnly, everything is lax. The
+// "0 but true" forms accepted with WithZeroButTrue are ZeroButTrueKind, and an
+// empty string accepted with WithEmptyAsUndef is UndefKind. The kind is
+// meaningless when there's an error.
+func ParseWithKind(version string, opts ...Option) (Version, Kind, error) {
+	return parse(version, newParseOptions(opts))
+}
+
+// kindOf works out the Kind of a freshly parsed version, given which grammar
+// produced it.
+func kindOf(pv Version, strict bool) Kind {
+	switch {
+	case pv.original == "undef":
+		return UndefKind
+	case strict && pv.qv:
+		return StrictDotted
+	case strict:
+		return StrictDecimal
+	case pv.qv:
+		return LaxDotted
+	default:
+		return LaxDecimal
+	}
+}
+
+func parse(version string, o *parseOptions) (Version, Kind, error) {
+	if o.trimSpace {
+		version = strings.TrimSpace(version)
+	}
+	if o.uppercaseV && strings.HasPrefix(version, "V") {
+		version = "v" + strings.TrimPrefix(version, "V")
+	}
+	if version == "" {
+		if o.emptyAsUndef {
+			return Undef(), UndefKind, nil
+		}
+		return Version{}, 0, ErrEmptyVersion
+	}
+
+	if o.zeroButTrue && o.grammar == anyGrammar && isZeroButTrue(version) {
+		return Version{original: version, version: []int64{0}},
+			ZeroButTrueKind, nil
+	}
+	if o.scientific {
+		version = expandScientific(version)
+	}
+	if o.fractionWidth < 1 || o.fractionWidth > maxFractionWidth {
+		return Version{}, 0, fmt.Errorf("invalid fraction width %d, "+
+			"expected 1 to %d", o.fractionWidth, maxFractionWidth)
+	}
+
+	// truncated input like "v" or "v." fails every grammar, so give it a
+	// clearer error than the generic one
+	if strings.TrimRight(version, ".") == "v" {
+		return Version{}, 0, errQvWithoutComponent
+	}
+
+	var pv Version
+	var strict bool
+	var err error
+	switch o.grammar {
+	case strictGrammar:
+		pv, err = parseStrict(version, o.fractionWidth)
+		strict = true
+	case laxGrammar:
+		pv, err = parseLax(version, o.fractionWidth)
+	default:
+		pv, strict, err = parseAny(version, o.fractionWidth)
+	}
+	if err != nil {
+		return Version{}, 0, err
+	}
+
+	if pv.alpha && o.alphaPolicy == AlphaReject {
+		return Version{}, 0, errors.New("invalid version string: " +
+			version + " is an alpha version")
+	}
+	return pv, kindOf(pv, strict), nil
+}
+
+// parseAny parses with whichever grammar fits best, also reporting whether
+// that was the strict one.
+func parseAny(version string, width int) (Version, bool, error) {
+	var laxBuf [laxSubmatches]string
+	var strictBuf [strictSubmatches]string
+	laxMatch := submatches(laxRegexp, version, laxBuf[:])
+	strictMatch := submatches(strictRegexp, version, strictBuf[:])
+
+	// the regexes are only anchored at the end, so a match on just the tail
+	// of the string doesn't count
+	if laxMatch != nil && laxMatch[0] != version {
+		laxMatch = nil
+	}
+	if strictMatch != nil && strictMatch[0] != version {
+		strictMatch = nil
+	}
 
 	// lax needs to be checked first, since it can throw an error
 	if laxMatch != nil {
 		if strictMatch == nil {
-			return laxVersion(laxMatch)
+			lax, err := laxVersion(laxMatch, width)
+			return lax, false, err
 		}
 		if len(laxMatch[0]) > len(strictMatch[0]) {
-			lax, err := laxVersion(laxMatch)
+			lax, err := laxVersion(laxMatch, width)
 			if err == nil {
-				return lax, nil
+				return lax, false, nil
 			}
 		}
 	}
 
 	// try strict next
 	if strictMatch != nil {
-		return strictVersion(strictMatch), nil
+		strict, err := strictVersion(strictMatch, width)
+		return strict, true, err
+	}
+
+	return Version{}, false, errors.New("invalid version string: " +
+		version)
+}
+
+func parseStrict(version string, width int) (Version, error) {
+	var buf [strictSubmatches]string
+	strictMatch := submatches(strictRegexp, version, buf[:])
+	if strictMatch == nil || strictMatch[0] != version {
+		if IsLax(version) && HasLeadingZero(version) {
+			return Version{}, errors.New("invalid strict version " +
+				"string: " + version + " has a leading zero, " +
+				"strict integer parts can't have one")
+		}
+		if IsLax(version) && strings.HasPrefix(version, "v") &&
+			!strings.Contains(version, "_") &&
+			strings.Count(version, ".") < 2 {
+			return Version{}, errors.New("invalid strict version " +
+				"string: " + version + " has too few " +
+				"components, strict dotted versions need at " +
+				"least three")
+		}
+		if IsValid(version) {
+			return Version{}, errors.New("invalid strict version " +
+				"string: " + version + " is only valid as a lax " +
+				"version")
+		}
+		return Version{}, errors.New("invalid strict version string: " +
+			version)
+	}
+	return strictVersion(strictMatch, width)
+}
+
+func parseLax(version string, width int) (Version, error) {
+	var buf [laxSubmatches]string
+	laxMatch := submatches(laxRegexp, version, buf[:])
+	if laxMatch == nil || laxMatch[0] != version {
+		return Version{}, errors.New("invalid lax version string: " +
+			version)
+	}
+	return laxVersion(laxMatch, width)
+}
+
+// ParseStrict parses a string into a Version, accepting only the strict
+// versioning scheme. This is closer to what Perl's version.pm considers
+// acceptable for new code; lax-only forms such as "1.2_3" or "undef" are
+// rejected with an error. It's shorthand for Parse with WithStrictOnly.
+func ParseStrict(version string) (Version, error) {
+	return Parse(version, WithStrictOnly())
+}
+
+// ParseLax parses a string into a Version, applying only the lax versioning
+// scheme. This is useful for legacy CPAN metadata. Since every strict version
+// is also a lax one, and Parse only accepts a string that matches in full,
+// there's no input where Parse and ParseLax diverge: they accept the same
+// strings and give identical versions. The only difference is the wording of
+// the error for strings that aren't lax versions at all. It's shorthand for
+// Parse with WithLaxOnly.
+func ParseLax(version string) (Version, error) {
+	return Parse(version, WithLaxOnly())
+}
+
+// Declare parses a string with Perl's version->declare (or qv()) semantics,
+// which force the qv interpretation of a decimal version. Declare("1.2") is the
+// same as Parse("v1.2"), i.e. {1, 2, 0}, where Parse("1.2") would give
+// {1, 200}. Versions that are already qv are returned as Parse would, as is
+// "undef". Otherwise the original has a "v" prepended, with a leading or
+// trailing dot filled in to keep it parseable, so Declare(".1") has the
+// original "v0.1".
+func Declare(version string) (Version, error) {
+	pv, err := Parse(version)
+	if err != nil || pv.qv || pv.IsUndef() {
+		return pv, err
+	}
+	declared := strings.TrimSuffix(version, ".")
+	if strings.HasPrefix(declared, ".") {
+		declared = "0" + declared
+	}
+	return Parse("v" + declared)
+}
+
+// ParsePerlConfig parses the version printed by perl itself, either by
+// `perl -V:version`, which looks like "version='5.34.0';", or by
+// `perl -e 'print $]'`, which is a bare "5.034000". The "version=" key, the
+// quotes, the trailing semicolon and any surrounding whitespace are stripped
+// before parsing.
+func ParsePerlConfig(config string) (Version, error) {
+	trimmed := strings.TrimSpace(config)
+	trimmed = strings.TrimSuffix(trimmed, ";")
+	trimmed = strings.TrimPrefix(trimmed, "version=")
+	if len(trimmed) >= 2 && (trimmed[0] == '\'' || trimmed[0] == '"') &&
+		trimmed[len(trimmed)-1] == trimmed[0] {
+		trimmed = trimmed[1 : len(trimmed)-1]
+	}
+	return Parse(trimmed)
+}
+
+// ParseAll parses every string in versions, returning the results in the same
+// order. Unlike Parse it doesn't stop at the first failure: the entries that
+// failed are left as zero-value Versions, and the returned error joins the
+// errors for every one of them.
+func ParseAll(versions []string) ([]Version, error) {
+	parsed := make([]Version, len(versions))
+	var errs []error
+	for i, version := range versions {
+		pv, err := Parse(version)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", version, err))
+			continue
+		}
+		parsed[i] = pv
+	}
+	return parsed, errors.Join(errs...)
+}
+
+// SortStrings sorts version strings in place, oldest first, by the same
+// ordering as CompareStable, so alpha versions go just before the stable
+// version with the same components. The sort is stable, so equivalent
+// spellings like "1.002" and "v1.2.0" keep their order. If any of the strings
+// doesn't parse, the slice is left alone, and the error is the one ParseAll
+// would give.
+func SortStrings(ss []string) error {
+	parsed, err := ParseAll(ss)
+	if err != nil {
+		return err
+	}
+	type entry struct {
+		version Version
+		str     string
+	}
+	entries := make([]entry, len(ss))
+	for i := range ss {
+		entries[i] = entry{parsed[i], ss[i]}
+	}
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		return a.version.CompareStable(&b.version)
+	})
+	for i := range entries {
+		ss[i] = entries[i].str
+	}
+	return nil
+}
+
+// Reasons returned by Explain. These are stable, so they're safe to match
+// against.
+const (
+	ReasonEmpty               = "empty version string"
+	ReasonAlphaWithoutDecimal = "alpha part without decimal"
+	ReasonNoMatchingGrammar   = "no matching version grammar"
+	ReasonIntegerOverflow     = "integer overflow in version component"
+)
+
+// Explain checks whether a string parses as a version, the same as IsValid,
+// and if it doesn't, returns a human-readable reason why not. The reason is
+// one of the Reason constants, and is empty when the version is valid.
+func Explain(version string) (bool, string) {
+	_, err := Parse(version)
+	switch {
+	case err == nil:
+		return true, ""
+	case version == "":
+		return false, ReasonEmpty
+	case errors.Is(err, errAlphaWithoutDecimal):
+		return false, ReasonAlphaWithoutDecimal
+	case errors.Is(err, errIntegerOverflow):
+		return false, ReasonIntegerOverflow
+	default:
+		return false, ReasonNoMatchingGrammar
+	}
+}
+
+// FromNumify is the inverse of Version.Numify, building a decimal version from
+// its numeric form. The integer part becomes the first component, and every
+// three digits of the fraction become a subsequent component, so 1.002003 is
+// "1.002003", or {1, 2, 3}. This can't be exact: trailing zero components
+// aren't kept by a float64 (1.002 and 1.002000 are the same number), and past
+// about 15 significant digits the float64 has already lost the components
+// anyway. Negative, non-finite, or out-of-range values give an undefined
+// version.
+func FromNumify(f float64) Version {
+	if math.IsNaN(f) || f < 0 || f >= math.MaxInt64 {
+		return Undef()
+	}
+	v, err := Parse(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return Undef()
+	}
+	return v
+}
+
+// FindAll finds every version embedded in a blob of text, such as a changelog,
+// in the order they appear. A version has to stand on its own, so it can't
+// directly follow a letter, digit, underscore or dot, nor be directly followed
+// by a letter, digit or underscore; "undefined" and "x86" don't count, but
+// "released 1.2.3." does. Anything matching the lax grammar that doesn't then
+// parse is skipped.
+func FindAll(text string) []Version {
+	var found []Version
+	for _, loc := range laxSearchRegexp.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 && isVersionByte(text[start-1], true) {
+			continue
+		}
+		if end < len(text) && isVersionByte(text[end], false) {
+			continue
+		}
+		pv, err := Parse(text[start:end])
+		if err != nil {
+			continue
+		}
+		found = append(found, pv)
 	}
+	return found
+}
 
-	return Version{}, errors.New("invalid version string: " + version)
+// isVersionByte checks whether b would run on from a version found in text,
+// with dots only counting before it.
+func isVersionByte(b byte, before bool) bool {
+	switch {
+	case b >= '0' && b <= '9', b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z',
+		b == '_':
+		return true
+	case b == '.':
+		return before
+	default:
+		return false
+	}
 }
 
 // Undef returns a new, undefined version.
@@ -83,6 +504,37 @@ func CompatibleWith(candidate, target string) bool {
 	return candidatePv.GreaterThanOrEqual(&targetPv)
 }
 
+// CompareStrings parses both versions and compares them, the same as
+// Version.Compare. Unlike CompatibleWith, it returns an error rather than
+// panicking if either doesn't parse.
+func CompareStrings(a, b string) (int, error) {
+	aPv, bPv, err := parseMulti(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return aPv.Compare(&bPv), nil
+}
+
+// EqualStrings parses both versions and checks whether they're the same, as
+// with Version.Equal. It returns an error if either doesn't parse.
+func EqualStrings(a, b string) (bool, error) {
+	aPv, bPv, err := parseMulti(a, b)
+	if err != nil {
+		return false, err
+	}
+	return aPv.Equal(&bPv), nil
+}
+
+// LessThanStrings parses both versions and checks whether a is older than b,
+// as with Version.LessThan. It returns an error if either doesn't parse.
+func LessThanStrings(a, b string) (bool, error) {
+	aPv, bPv, err := parseMulti(a, b)
+	if err != nil {
+		return false, err
+	}
+	return aPv.LessThan(&bPv), nil
+}
+
 // MustParse is for parsing a version string that must be valid. It panics
 // if it can't parse the string. You probably want Parse(), unless you're
 // dealing with an internal cache.
@@ -94,8 +546,77 @@ func MustParse(version string) Version {
 	return v
 }
 
+// HasLeadingZero reports whether the integer part of the version, the digits
+// before the first dot (after any leading "v"), has a leading zero, as in "01"
+// or "v01.2.3". Perl's strict grammar forbids that, though the lax one allows
+// it. A lone "0" doesn't count.
+func HasLeadingZero(version string) bool {
+	integer := strings.TrimPrefix(version, "v")
+	end := 0
+	for end < len(integer) && integer[end] >= '0' && integer[end] <= '9' {
+		end++
+	}
+	return end > 1 && integer[0] == '0'
+}
+
+// ParseOrUndef parses a string into a Version like Parse, but returns Undef
+// instead of an error when it doesn't parse. That includes the empty string,
+// which is handy for optional metadata fields that may be missing.
+func ParseOrUndef(version string) Version {
+	pv, err := Parse(version)
+	if err != nil {
+		return Undef()
+	}
+	return pv
+}
+
+// IsLax reports whether the string matches the lax versioning grammar, the
+// same as Perl's version::is_lax. This only checks the grammar, so strings
+// such as "1_0" count as lax even though Parse rejects them.
+func IsLax(version string) bool {
+	return matchesFully(laxRegexp, version)
+}
+
+// IsStrict reports whether the string matches the strict versioning grammar,
+// the same as Perl's version::is_strict.
+func IsStrict(version string) bool {
+	return matchesFully(strictRegexp, version)
+}
+
+// IsStrictDotted reports whether the string is a strict dotted version, i.e.
+// a "v" followed by at least three components, each of the components after
+// the first having at most three digits. "v1.2.3" is strict dotted, but "v1.2"
+// is only valid as a lax version.
+func IsStrictDotted(version string) bool {
+	return matchesFully(strictDottedRegexp, version)
+}
+
 // IsValid returns true if the version is parseable.
 func IsValid(version string) bool {
 	_, err := Parse(version)
 	return err == nil
 }
+
+// IsAmbiguous reports whether a version string means something different
+// depending on whether its author intended it as a decimal or a dotted (qv)
+// version. The decimal reading is what Parse gives, and the qv reading is what
+// Declare gives; the string is ambiguous when both succeed but their
+// components differ.
+//
+// Every strict version parses the same under the lax grammar, so the grammars
+// themselves never disagree. The ambiguity is in unadorned decimals with a
+// single dot, such as "1.2" ({1, 200} or {1, 2, 0}) and "1.002003". Anything
+// with a "v", two or more dots, or no dot at all can only be read one way, and
+// neither can decimals with exactly three digits after the dot, like "1.002",
+// which is v1.2.0 either way. Unparseable strings aren't ambiguous.
+func IsAmbiguous(version string) bool {
+	decimal, err := Parse(version)
+	if err != nil {
+		return false
+	}
+	declared, err := Declare(version)
+	if err != nil {
+		return false
+	}
+	return !decimal.Equal(&declared)
+}
@@ -16,6 +16,8 @@ package perl_version
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -23,26 +25,130 @@ import (
 var (
 	errAlphaWithoutDecimal = errors.New("invalid version format: alpha " +
 		"without decimal")
+	errQvWithoutComponent = errors.New("invalid version format: qv " +
+		"prefix 'v' requires at least one version component")
+	errNegativeComponent = errors.New("invalid version: negative " +
+		"component")
+	errIntegerOverflow = errors.New("invalid version format: integer " +
+		"overflow in version component")
+	// only returned if the regexes and the conversion code disagree, which
+	// would be a bug here rather than in the input
+	errUnrecognizedForm = errors.New("invalid version format: " +
+		"unrecognized version form")
 )
 
-func mustParseInt64(s string) int64 {
-	val, err := strconv.Atoi(s)
+// the number of submatches, including the whole match, in the lax and strict
+// regexes, for sizing the buffers submatches fills
+const (
+	laxSubmatches    = 15
+	strictSubmatches = 7
+)
+
+// submatches is FindStringSubmatch, but filling in buf rather than allocating
+// a new slice, which saves an allocation per match when buf is on the stack.
+// buf has to be big enough for all of the regex's submatches.
+func submatches(re *regexp.Regexp, s string, buf []string) []string {
+	indices := re.FindStringSubmatchIndex(s)
+	if indices == nil {
+		return nil
+	}
+	matches := buf[:len(indices)/2]
+	for i := range matches {
+		if start := indices[2*i]; start >= 0 {
+			matches[i] = s[start:indices[2*i+1]]
+		} else {
+			matches[i] = ""
+		}
+	}
+	return matches
+}
+
+// checkComponents makes sure none of the components are negative, which the
+// parser can't produce, but decoding or building a version can.
+func checkComponents(components []int64) error {
+	for _, component := range components {
+		if component < 0 {
+			return errNegativeComponent
+		}
+	}
+	return nil
+}
+
+// parseInt64 parses a version component. The grammar guarantees it's all
+// digits, but not that it fits in an int64.
+func parseInt64(s string) (int64, error) {
+	val, err := strconv.ParseInt(s, 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("%w: %s", errIntegerOverflow, s)
+	}
 	if err != nil {
-		// if we make it this far and fail, something's fundamentally
-		// wrong with the code.
-		panic(err)
+		return 0, errUnrecognizedForm
 	}
-	return int64(val)
+	return val, nil
 }
 
-func dottedToMinors(s string) []int64 {
+func dottedToMinors(s string) ([]int64, error) {
 	s = strings.TrimPrefix(s, ".")
 	raw := strings.Split(s, ".")
 	minors := make([]int64, len(raw))
 	for i, s := range raw {
-		minors[i] = mustParseInt64(s)
+		minor, err := parseInt64(s)
+		if err != nil {
+			return nil, err
+		}
+		minors[i] = minor
+	}
+	return minors, nil
+}
+
+var scientificRegexp = regexp.MustCompile(
+	`^([0-9]+)(?:\.([0-9]*))?[eE]([+-]?[0-9]{1,4})$`)
+
+// expandScientific rewrites a decimal in scientific notation as a plain
+// decimal, by moving the decimal point, so that the digits (and with them the
+// components) are kept exactly. Anything else is returned unchanged.
+func expandScientific(s string) string {
+	match := scientificRegexp.FindStringSubmatch(s)
+	if match == nil {
+		return s
 	}
-	return minors
+	digits := match[1] + match[2]
+	exponent, _ := strconv.Atoi(match[3])
+	point := len(match[1]) + exponent
+	var expanded string
+	switch {
+	case point <= 0:
+		expanded = "0." + strings.Repeat("0", -point) + digits
+	case point >= len(digits):
+		expanded = digits + strings.Repeat("0", point-len(digits))
+	default:
+		expanded = digits[:point] + "." + digits[point:]
+	}
+	// drop leading zeros from the integer part, keeping at least one
+	integer := strings.TrimLeft(expanded, "0")
+	if integer == "" || integer[0] == '.' {
+		integer = "0" + integer
+	}
+	return integer
+}
+
+// isZeroButTrue checks for Perl's idioms for a zero that's still true in
+// boolean context.
+func isZeroButTrue(s string) bool {
+	return s == "0 but true" || strings.EqualFold(s, "0E0")
+}
+
+// matchesFully checks whether re matches the entirety of s. The version regexes
+// are only anchored at the end, so a match has to be checked for its start.
+func matchesFully(re *regexp.Regexp, s string) bool {
+	loc := re.FindStringIndex(s)
+	return loc != nil && loc[0] == 0 && loc[1] == len(s)
+}
+
+// matchesFullyBytes is matchesFully for a byte slice.
+func matchesFullyBytes(re *regexp.Regexp, b []byte) bool {
+	loc := re.FindIndex(b)
+	return loc != nil && loc[0] == 0 && loc[1] == len(b)
 }
 
 func min(a, b int) int {
@@ -59,34 +165,42 @@ func max(a, b int) int {
 	return b
 }
 
-func getFractionValue(s string) []int64 {
+// maxExactFloatInt is 2^53, the first integer a float64 can't always hold
+// exactly, and maxExactPowerOfTen the largest power of ten it holds exactly.
+const (
+	maxExactFloatInt   = 1 << 53
+	maxExactPowerOfTen = 22
+)
+
+// maxFractionWidth is the most digits a fraction component can have and still
+// always fit in an int64.
+const maxFractionWidth = 18
+
+// getFractionValue splits the digits of a decimal fraction into components of
+// width digits each, padding the last with zeros, as Perl does with a width of
+// three.
+func getFractionValue(s string, width int) ([]int64, error) {
+	s = strings.TrimPrefix(s, ".")
 	if s == "" {
 		// should only happen in lax decimal shenanigans
-		return nil
+		return nil, nil
 	}
-	s = strings.TrimPrefix(s, ".")
-	expectedValues := (len(s) / 3) + 1
-	if (len(s) % 3) == 0 {
-		expectedValues--
-	}
-	stringValues := make([]string, expectedValues)
-	currentString := ""
-	for i := range s {
-		if i%3 == 0 && i != 0 {
-			stringValues[i/3-1] = currentString
-			currentString = ""
+	values := make([]int64, (len(s)+width-1)/width)
+	for i := range values {
+		var value int64
+		for j := i * width; j < (i+1)*width; j++ {
+			value *= 10
+			// the last value is padded with zeros until it's full
+			// width
+			if j >= len(s) {
+				continue
+			}
+			if s[j] < '0' || s[j] > '9' {
+				return nil, errUnrecognizedForm
+			}
+			value += int64(s[j] - '0')
 		}
-		currentString += string(s[i])
-	}
-	// have to pad the last value with zeros until three wide
-	for i := len(currentString); i < 3; i++ {
-		currentString += "0"
+		values[i] = value
 	}
-	stringValues[len(stringValues)-1] = currentString
-	values := make([]int64, len(stringValues))
-	for i, s := range stringValues {
-		values[i] = mustParseInt64(s)
-	}
-
-	return values
+	return values, nil
 }
@@ -0,0 +1,61 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+package perl_version
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Perl's v-strings: "v1.2.3" is, to Perl, also the string chr(1).chr(2).chr(3),
+// one character per component. These convert between the two.
+
+// VString returns the version as a Perl v-string, with each component as a
+// character of that codepoint, so "v1.2.3" gives "\x01\x02\x03" and
+// "v5.36.0" gives "\x05$\x00". A component that isn't a valid Unicode
+// codepoint, such as one above U+10FFFF or a surrogate half, comes out as
+// U+FFFD, the replacement character, so it won't round-trip.
+func (v *Version) VString() string {
+	var sb strings.Builder
+	for _, component := range v.components() {
+		if component < 0 || component > 0x10FFFF {
+			sb.WriteRune(utf8.RuneError)
+			continue
+		}
+		sb.WriteRune(rune(component))
+	}
+	return sb.String()
+}
+
+// FromVString builds a version from a Perl v-string, using each character's
+// codepoint as a component, so "\x01\x02\x03" gives "v1.2.3". It's parsed
+// like any other "v" version, so shorter v-strings are padded to three
+// components. Invalid UTF-8 is read as U+FFFD, and the empty string gives
+// Undef.
+func FromVString(s string) Version {
+	if s == "" {
+		return Undef()
+	}
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		parts = append(parts, strconv.Itoa(int(r)))
+	}
+	pv, err := Parse("v" + strings.Join(parts, "."))
+	if err != nil {
+		// every codepoint fits in an int64, so this can't fail
+		panic("logic error: unparseable v-string version: " + err.Error())
+	}
+	return pv
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+package perl_version
+
+import "testing"
+
+func TestVString(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"v1.2.3", "\x01\x02\x03"},
+		{"v5.36.0", "\x05$\x00"},
+		{"v1", "\x01\x00\x00"},
+		{"v65.66.67.68", "ABCD"},
+		{"v960.8364", "π€\x00"},
+		{"v1.1114112", "\x01�\x00"},
+		{"undef", "\x00"},
+	}
+	for _, test := range tests {
+		pv := MustParse(test.version)
+		if actual := pv.VString(); actual != test.expected {
+			t.Errorf("NewPerlVersion(%q).VString() => %q, expected %q",
+				test.version, actual, test.expected)
+		}
+	}
+}
+
+func TestFromVString(t *testing.T) {
+	tests := []struct {
+		vstring  string
+		expected string
+	}{
+		{"\x01\x02\x03", "v1.2.3"},
+		{"\x05$\x00", "v5.36.0"},
+		{"\x01", "v1"},
+		{"ABCD", "v65.66.67.68"},
+		{"π€", "v960.8364"},
+		{"\xff", "v65533"},
+		{"", "undef"},
+	}
+	for _, test := range tests {
+		pv := FromVString(test.vstring)
+		if pv.Raw() != test.expected {
+			t.Errorf("FromVString(%q) => %q, expected %q", test.vstring,
+				pv.Raw(), test.expected)
+		}
+		expected := MustParse(test.expected)
+		if !pv.StrictEqual(&expected) {
+			t.Errorf("FromVString(%q) => %v, expected %v", test.vstring,
+				pv, expected)
+		}
+	}
+}
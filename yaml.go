@@ -0,0 +1,48 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+//go:build yaml
+
+package perl_version
+
+// YAML support, for reading META.yml files. It's behind the "yaml" build tag
+// so the package stays dependency-free unless it's asked for.
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalYAML implements the yaml.Marshaler interface. The version is encoded
+// as its original string. Unlike the other methods this has a value receiver,
+// since the yaml encoder won't take the address of a struct field.
+func (v Version) MarshalYAML() (interface{}, error) {
+	return v.original, nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface. The node has to be
+// a scalar, which is parsed as a version string.
+func (v *Version) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("invalid version: expected a YAML scalar "+
+			"at line %d", value.Line)
+	}
+	pv, err := Parse(value.Value)
+	if err != nil {
+		return err
+	}
+	*v = pv
+	return nil
+}
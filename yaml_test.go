@@ -0,0 +1,60 @@
+// Copyright (c) 2022 Charlie Burnett
+//
+// Permission to use, copy, modify, and distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+//go:build yaml
+
+package perl_version
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestVersion_YAML(t *testing.T) {
+	type meta struct {
+		Name    string  `yaml:"name"`
+		Version Version `yaml:"version"`
+	}
+	for _, version := range []string{"undef", "1.02_03", "v1.2.3"} {
+		input := meta{Name: "Foo-Bar", Version: MustParse(version)}
+		data, err := yaml.Marshal(&input)
+		if err != nil {
+			t.Fatalf("yaml.Marshal(%q) returned error: %v", version,
+				err)
+		}
+		var actual meta
+		if err := yaml.Unmarshal(data, &actual); err != nil {
+			t.Fatalf("yaml.Unmarshal(%q) returned error: %v",
+				data, err)
+		}
+		if !reflect.DeepEqual(actual, input) {
+			t.Errorf("YAML round-trip of %q => %+v, expected %+v",
+				version, actual, input)
+		}
+	}
+
+	var actual meta
+	err := yaml.Unmarshal([]byte("version: [1, 2]\n"), &actual)
+	if err == nil {
+		t.Errorf("yaml.Unmarshal() of a sequence => nil error, " +
+			"expected error")
+	}
+	err = yaml.Unmarshal([]byte("version: foo\n"), &actual)
+	if err == nil {
+		t.Errorf("yaml.Unmarshal() of %q => nil error, expected error",
+			"foo")
+	}
+}